@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// --------------------------------------------------------------------------------
+// GRAVAÇÃO SEGURA DE ARQUIVOS
+// --------------------------------------------------------------------------------
+
+// Quanto tempo esperar pela trava de um arquivo antes de desistir
+var esperaTravaArquivo = 10 * time.Second
+
+// Substitui o arquivo de forma atômica: escreve num temporário na mesma
+// pasta, faz fsync e renomeia por cima. Se o processo cair no meio, o arquivo
+// antigo continua inteiro; se escrever falhar, ele não é alterado.
+func gravarArquivoAtomico(caminho string, escrever func(w io.Writer) error) (err error) {
+	pasta := filepath.Dir(caminho)
+	tmp, err := os.CreateTemp(pasta, "."+filepath.Base(caminho)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	bw := bufio.NewWriter(tmp)
+	if err := escrever(bw); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), caminho); err != nil {
+		return err
+	}
+	// O rename só está garantido no disco depois do fsync da pasta
+	return sincronizarPasta(pasta)
+}
+
+// Acrescenta ao fim do arquivo (criando-o se preciso) e faz fsync.
+// novo indica que o arquivo estava vazio, para escrever o cabeçalho.
+func anexarArquivo(caminho string, escrever func(w io.Writer, novo bool) error) error {
+	f, err := os.OpenFile(caminho, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	if err := escrever(bw, info.Size() == 0); err != nil {
+		f.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Corta do fim do arquivo uma linha sem '\n', deixada por uma gravação
+// interrompida, para a próxima linha anexada não se juntar a ela. No CSV
+// a linha não pode ser só fechada: um campo entre aspas cortado no meio
+// estragaria as linhas seguintes. Chamar com a trava do arquivo.
+func descartarLinhaCortada(caminho string) error {
+	f, err := os.OpenFile(caminho, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	fim := info.Size()
+	bloco := make([]byte, 4096)
+	for fim > 0 {
+		inicio := max(fim-int64(len(bloco)), 0)
+		trecho := bloco[:fim-inicio]
+		if _, err := f.ReadAt(trecho, inicio); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(trecho, '\n'); i >= 0 {
+			fim = inicio + int64(i) + 1
+			break
+		}
+		fim = inicio
+	}
+	if fim == info.Size() {
+		return nil
+	}
+	fmt.Printf("[STORE] %s: linha final incompleta descartada (%d bytes).\n", filepath.Base(caminho), info.Size()-fim)
+	if err := f.Truncate(fim); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Executa fn com a trava exclusiva do arquivo, que vale também entre
+// processos (dois servidores apontando para a mesma OutputFolder).
+// A trava é um arquivo ao lado: <caminho>.lock.
+func comTrava(caminho string, fn func() error) error {
+	destravar, err := travarArquivo(caminho+".lock", esperaTravaArquivo)
+	if err != nil {
+		return fmt.Errorf("trava de %s: %v", filepath.Base(caminho), err)
+	}
+	defer destravar()
+	return fn()
+}
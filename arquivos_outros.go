@@ -0,0 +1,41 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// Trava que sobrou de um processo que morreu segurando-a
+const travaAbandonada = 2 * time.Minute
+
+// Sem flock (Windows): a trava é criar o arquivo com O_EXCL e apagá-lo ao
+// destravar. Uma trava mais velha que travaAbandonada é descartada.
+func travarArquivo(caminho string, espera time.Duration) (func(), error) {
+	limite := time.Now().Add(espera)
+	for {
+		f, err := os.OpenFile(caminho, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(caminho) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(caminho); err == nil && time.Since(info.ModTime()) > travaAbandonada {
+			os.Remove(caminho)
+			continue
+		}
+		if time.Now().After(limite) {
+			return nil, errors.New("tempo esgotado esperando outro processo")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Fora do unix não há fsync de pasta (no Windows, abri-la nem é permitido)
+func sincronizarPasta(pasta string) error {
+	return nil
+}
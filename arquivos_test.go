@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGravarArquivoAtomico(t *testing.T) {
+	pasta := t.TempDir()
+	caminho := filepath.Join(pasta, "monitor_registros.csv")
+	os.WriteFile(caminho, []byte("conteúdo antigo\n"), 0644)
+
+	// Falha no meio da escrita: o arquivo antigo fica intacto e o temporário some
+	err := gravarArquivoAtomico(caminho, func(w io.Writer) error {
+		w.Write([]byte("metade"))
+		return errors.New("disco cheio")
+	})
+	if err == nil {
+		t.Fatal("esperava o erro da escrita")
+	}
+	if dados, _ := os.ReadFile(caminho); string(dados) != "conteúdo antigo\n" {
+		t.Errorf("arquivo após falha = %q", dados)
+	}
+	if sobras, _ := filepath.Glob(filepath.Join(pasta, ".*.tmp")); len(sobras) != 0 {
+		t.Errorf("temporários deixados para trás: %v", sobras)
+	}
+
+	if err := gravarArquivoAtomico(caminho, func(w io.Writer) error {
+		_, err := w.Write([]byte("novo\n"))
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if dados, _ := os.ReadFile(caminho); string(dados) != "novo\n" {
+		t.Errorf("arquivo = %q", dados)
+	}
+}
+
+// Writer que falha, para conferir que o erro do csv.Writer chega ao chamador
+type writerComFalha struct{}
+
+func (writerComFalha) Write(p []byte) (int, error) { return 0, errors.New("falha de E/S") }
+
+func TestEscreverCSVPropagaErro(t *testing.T) {
+	if err := escreverMonitorCSV(writerComFalha{}, []MonitorEntry{{Nome: "Charizard ex"}}); err == nil {
+		t.Error("escreverMonitorCSV ignorou o erro do writer")
+	}
+	if err := escreverHistoricoCSV(writerComFalha{}, []Observacao{{Nome: "Charizard ex"}}, true); err == nil {
+		t.Error("escreverHistoricoCSV ignorou o erro do writer")
+	}
+	if err := escreverResultadosCSV(writerComFalha{}, []CardResult{{Nome: "Charizard ex"}}, false); err == nil {
+		t.Error("escreverResultadosCSV ignorou o erro do writer")
+	}
+}
+
+// Dois stores na mesma pasta (como dois processos) gravando ao mesmo tempo
+func TestStoresConcorrentesMesmaPasta(t *testing.T) {
+	configOriginal := config
+	t.Cleanup(func() { config = configOriginal })
+	config.OutputFolder = t.TempDir()
+
+	var stores []Store
+	for range 2 {
+		st, err := abrirCSVStore(config.OutputFolder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores = append(stores, st)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 40 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o := Observacao{Data: "2024-01-01 10:00:00", Checagem: "c1", Nome: "Pikachu", Colecao: "SVP", Numero: "27", Preco: float64(i), Selecionada: true}
+			if err := stores[i%2].AnexarObservacoes([]Observacao{o}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	hist, err := stores[0].Observacoes(FiltroObservacoes{})
+	if err != nil || len(hist) != 40 {
+		t.Fatalf("histórico com %d observações, err %v", len(hist), err)
+	}
+	dados, _ := os.ReadFile(filepath.Join(config.OutputFolder, config.HistoricoCSV))
+	if n := strings.Count(string(dados), strings.Join(colunasHistoricoCSV, ";")); n != 1 {
+		t.Errorf("cabeçalho aparece %d vezes", n)
+	}
+	resumo, err := carregarMonitorCSV(filepath.Join(config.OutputFolder, config.MonitorCSV))
+	// Cada store só lê o que o outro anexou, mas o resumo gravado por último vê tudo
+	if err != nil || len(resumo) != 1 || resumo[0].PrecoAtual != hist[39].Preco {
+		t.Errorf("resumo = %+v, err %v", resumo, err)
+	}
+}
+
+func TestJSONLLinhaCortada(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), jsonlObservacoes)
+	a := Observacao{Data: "2024-01-01 10:00:00", Nome: "Pikachu", Preco: 10}
+	b := Observacao{Data: "2024-01-02 10:00:00", Nome: "Pikachu", Preco: 12}
+	if err := anexarJSONL(caminho, []Observacao{a}); err != nil {
+		t.Fatal(err)
+	}
+	// Crash no meio da gravação seguinte
+	f, _ := os.OpenFile(caminho, os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write([]byte(`{"data":"2024-01-01 11:00`))
+	f.Close()
+
+	if err := anexarJSONL(caminho, []Observacao{b}); err != nil {
+		t.Fatal(err)
+	}
+	lista, err := lerJSONL[Observacao](caminho)
+	if err != nil || len(lista) != 2 || lista[0] != a || lista[1] != b {
+		t.Errorf("lido = %+v, err %v", lista, err)
+	}
+}
+
+func TestCSVLinhaCortada(t *testing.T) {
+	configOriginal := config
+	t.Cleanup(func() { config = configOriginal })
+	config.OutputFolder = t.TempDir()
+	st, err := abrirCSVStore(config.OutputFolder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := Observacao{Data: "2024-01-01 10:00:00", Checagem: "c1", Nome: "Pikachu", Colecao: "SVP", Numero: "27", Preco: 10, Selecionada: true}
+	b := a
+	b.Data, b.Checagem, b.Preco = "2024-01-02 10:00:00", "c2", 12
+	r := CardResult{Nome: "Pikachu", Colecao: "SVP", Numero: "27", Condicao: CondicaoNM, Preco: 10, Lingua: LinguaPT, Loja: `Loja "Alfa"`}
+	if err := st.AnexarObservacoes([]Observacao{a}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SalvarResultados([]CardResult{r}, a.Data); err != nil {
+		t.Fatal(err)
+	}
+	// Crash no meio da gravação seguinte dos dois arquivos
+	cortar := func(nome, linha string) {
+		f, _ := os.OpenFile(filepath.Join(config.OutputFolder, nome), os.O_APPEND|os.O_WRONLY, 0644)
+		f.Write([]byte(linha))
+		f.Close()
+	}
+	cortar(config.HistoricoCSV, "2024-01-01 11:00:00;c9;Pikachu;SVP")
+	cortar(config.SaidaCSV, `Pikachu;SVP;27;NM;1;9.00;9.00;PT;"Loja`)
+
+	if hist, err := st.Observacoes(FiltroObservacoes{}); err != nil || len(hist) != 1 || hist[0] != a {
+		t.Errorf("histórico com a linha cortada = %+v, err %v", hist, err)
+	}
+	if err := st.AnexarObservacoes([]Observacao{b}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SalvarResultados([]CardResult{r}, b.Data); err != nil {
+		t.Fatal(err)
+	}
+	if hist, err := st.Observacoes(FiltroObservacoes{}); err != nil || len(hist) != 2 || hist[0] != a || hist[1] != b {
+		t.Errorf("histórico = %+v, err %v", hist, err)
+	}
+	if rs, err := st.Resultados(); err != nil || len(rs) != 2 || rs[0] != r || rs[1] != r {
+		t.Errorf("resultados = %+v, err %v", rs, err)
+	}
+	if preco, ok, err := st.UltimoPreco("Pikachu", "SVP", "27"); err != nil || !ok || preco != 12 {
+		t.Errorf("último preço = %v (ok=%v), err %v", preco, ok, err)
+	}
+}
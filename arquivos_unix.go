@@ -0,0 +1,48 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// flock no arquivo de trava. O arquivo fica no disco; a trava some sozinha
+// se o processo morrer.
+func travarArquivo(caminho string, espera time.Duration) (func(), error) {
+	f, err := os.OpenFile(caminho, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	limite := time.Now().Add(espera)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			f.Close()
+			return nil, err
+		}
+		if time.Now().After(limite) {
+			f.Close()
+			return nil, errors.New("tempo esgotado esperando outro processo")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+func sincronizarPasta(pasta string) error {
+	d, err := os.Open(pasta)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
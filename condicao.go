@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// --------------------------------------------------------------------------------
+// CONDIÇÃO DAS CARTAS (escala da Liga)
+// --------------------------------------------------------------------------------
+
+// Condicao é a qualidade da carta na escala usada pela Liga
+type Condicao string
+
+const (
+	CondicaoM  Condicao = "M"  // Nova (Mint)
+	CondicaoNM Condicao = "NM" // Praticamente nova (Near Mint)
+	CondicaoSP Condicao = "SP" // Pouco usada (Slightly Played)
+	CondicaoMP Condicao = "MP" // Moderadamente usada (Moderately Played)
+	CondicaoHP Condicao = "HP" // Muito usada (Heavily Played)
+	CondicaoD  Condicao = "D"  // Danificada (Damaged)
+)
+
+// Escala completa, da melhor para a pior condição
+var escalaCondicoes = []Condicao{CondicaoM, CondicaoNM, CondicaoSP, CondicaoMP, CondicaoHP, CondicaoD}
+
+// Nomes por extenso que aparecem nos títulos da Liga
+var nomesCondicoes = map[string]Condicao{
+	"NOVA":                CondicaoM,
+	"MINT":                CondicaoM,
+	"PRATICAMENTE NOVA":   CondicaoNM,
+	"NEAR MINT":           CondicaoNM,
+	"POUCO USADA":         CondicaoSP,
+	"USADA LEVEMENTE":     CondicaoSP,
+	"SLIGHTLY PLAYED":     CondicaoSP,
+	"MODERADAMENTE USADA": CondicaoMP,
+	"USADA MODERADAMENTE": CondicaoMP,
+	"MODERATELY PLAYED":   CondicaoMP,
+	"MUITO USADA":         CondicaoHP,
+	"HEAVILY PLAYED":      CondicaoHP,
+	"DANIFICADA":          CondicaoD,
+	"DAMAGED":             CondicaoD,
+}
+
+// Converte o título exibido pelo site (ex.: "Praticamente Nova (NM)") na Condicao.
+// Retorna false se não reconhecer nenhuma condição no texto.
+func parseCondicao(titulo string) (Condicao, bool) {
+	titulo = strings.ToUpper(strings.TrimSpace(titulo))
+	if titulo == "" {
+		return "", false
+	}
+	// Procura a sigla isolada (evita que "NM" case com "M" ou "MP")
+	tokens := strings.FieldsFunc(titulo, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, t := range tokens {
+		for _, c := range escalaCondicoes {
+			if t == string(c) {
+				return c, true
+			}
+		}
+	}
+	// Sem sigla: tenta pelo nome por extenso, do mais longo para o mais curto
+	melhor := ""
+	for nome := range nomesCondicoes {
+		if strings.Contains(titulo, nome) && len(nome) > len(melhor) {
+			melhor = nome
+		}
+	}
+	if melhor != "" {
+		return nomesCondicoes[melhor], true
+	}
+	return "", false
+}
+
+// Valida a lista de condições recebida no request.
+// Lista vazia significa só NM, que era o comportamento original.
+func normalizaCondicoes(lista []string) ([]Condicao, error) {
+	if len(lista) == 0 {
+		return []Condicao{CondicaoNM}, nil
+	}
+	var conds []Condicao
+	for _, s := range lista {
+		c, ok := parseCondicao(s)
+		if !ok {
+			return nil, fmt.Errorf("condição inválida: %q (use M, NM, SP, MP, HP ou D)", s)
+		}
+		conds = append(conds, c)
+	}
+	return conds, nil
+}
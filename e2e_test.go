@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var charizard = CardInput{Nome: "Charizard ex", Colecao: "OBF", Numero: "125"}
+
+// Faz POST /scrape?flat=true com o corpo informado e devolve o status e o
+// array plano de resultados
+func postScrape(t *testing.T, corpo interface{}) (int, []CardResult) {
+	t.Helper()
+	payload, _ := json.Marshal(corpo)
+	req := httptest.NewRequest(http.MethodPost, "/scrape?flat=true", bytes.NewReader(payload))
+	rec := httptest.NewRecorder()
+	scrapeHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var resultados []CardResult
+	if err := json.NewDecoder(rec.Body).Decode(&resultados); err != nil {
+		t.Fatalf("resposta não é JSON: %v", err)
+	}
+	return rec.Code, resultados
+}
+
+func TestScrapeHandlerPadrao(t *testing.T) {
+	srv := iniciaMarketplaceFixture(t)
+
+	status, res := postScrape(t, ScrapeRequest{Cards: []CardInput{charizard}})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(res) != 1 {
+		t.Fatalf("esperava 1 oferta, veio %d: %+v", len(res), res)
+	}
+	r := res[0]
+	esperado := CardResult{
+		Nome: "Charizard ex", Colecao: "OBF", Numero: "125",
+		Condicao: CondicaoNM, Quantidade: 2, Preco: 350, PrecoTotal: 370,
+		Frete: 20, QuantidadePedida: 1, Lingua: LinguaPT,
+		Loja: "Loja Alfa", LojaID: "101", LojaURL: srv.URL + "/?view=store&id=101", Estado: "SP",
+	}
+	if r != esperado {
+		t.Errorf("oferta = %+v\nesperado %+v", r, esperado)
+	}
+
+	// O resultado também fica gravado no store
+	if gravados, err := store.Resultados(); err != nil || len(gravados) != 1 || gravados[0].Loja != "Loja Alfa" {
+		t.Errorf("resultados gravados = %+v, err %v", gravados, err)
+	}
+}
+
+func TestScrapeHandlerOpcoes(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+
+	comLingua := charizard
+	comLingua.Linguas = []string{"Inglês"}
+	duasCopias := charizard
+	duasCopias.Quantidade = 2
+
+	casos := []struct {
+		nome   string
+		req    ScrapeRequest
+		lojas  []string
+		totais []float64
+	}{
+		{"cheapest", ScrapeRequest{Cards: []CardInput{charizard}, Modo: "cheapest"},
+			[]string{"Loja Beta"}, []float64{320}},
+		{"all NM e SP", ScrapeRequest{Cards: []CardInput{charizard}, Modo: "all", Condicoes: []string{"NM", "SP"}},
+			[]string{"Loja Alfa", "Loja Beta", "Loja Gama"}, []float64{370, 320, 295}},
+		{"só português", ScrapeRequest{Cards: []CardInput{charizard}, Modo: "all", Condicoes: []string{"NM", "SP"}, Linguas: []string{"PT"}},
+			[]string{"Loja Alfa", "Loja Gama"}, []float64{370, 295}},
+		{"língua da carta sobrepõe a do request", ScrapeRequest{Cards: []CardInput{comLingua}, Linguas: []string{"PT"}},
+			[]string{"Loja Beta"}, []float64{320}},
+		{"todas as condições", ScrapeRequest{Cards: []CardInput{charizard}, Modo: "cheapest", Condicoes: []string{"M", "NM", "SP", "MP", "HP", "D"}},
+			[]string{"Loja Delta"}, []float64{168.90}},
+		{"quantidade pedida", ScrapeRequest{Cards: []CardInput{duasCopias}},
+			[]string{"Loja Alfa"}, []float64{720}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			status, res := postScrape(t, c.req)
+			if status != http.StatusOK {
+				t.Fatalf("status = %d", status)
+			}
+			if len(res) != len(c.lojas) {
+				t.Fatalf("esperava %d ofertas, veio %d: %+v", len(c.lojas), len(res), res)
+			}
+			for i, r := range res {
+				if r.Loja != c.lojas[i] || r.PrecoTotal != c.totais[i] {
+					t.Errorf("oferta %d = %s %.2f, esperado %s %.2f", i, r.Loja, r.PrecoTotal, c.lojas[i], c.totais[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScrapeHandlerSemOfertas(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+
+	cards := []CardInput{
+		{Nome: "Pikachu", Colecao: "SVP", Numero: "27"},          // só tem oferta MP
+		{Nome: "Carta Inexistente", Colecao: "XXX", Numero: "1"}, // 404
+	}
+	status, res := postScrape(t, ScrapeRequest{Cards: cards})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(res) != 0 {
+		t.Errorf("esperava nenhuma oferta, veio %+v", res)
+	}
+}
+
+func TestScrapeHandlerEnvelope(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+
+	cards := []CardInput{
+		charizard,
+		{Nome: "Pikachu", Colecao: "SVP", Numero: "27"},          // só tem oferta MP
+		{Nome: "Carta Inexistente", Colecao: "XXX", Numero: "1"}, // 404
+		{Nome: "Mew ex", Colecao: "NOV", Numero: "1"},            // layout novo
+	}
+	payload, _ := json.Marshal(ScrapeRequest{Cards: cards})
+	rec := httptest.NewRecorder()
+	scrapeHandler(rec, httptest.NewRequest(http.MethodPost, "/scrape", bytes.NewReader(payload)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var resp ScrapeResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Cartas) != len(cards) {
+		t.Fatalf("esperava %d entradas, veio %+v", len(cards), resp.Cartas)
+	}
+	esperado := []struct {
+		status     string
+		classe     string
+		resultados int
+	}{
+		{StatusCartaOK, "", 1},
+		{StatusCartaSemOfertas, "", 0},
+		{StatusCartaNaoEncontrada, ClasseHTTP, 0},
+		{StatusCartaErro, ClasseSeletor, 0},
+	}
+	for i, e := range esperado {
+		c := resp.Cartas[i]
+		if c.Card.Nome != cards[i].Nome || c.Status != e.status || c.ClasseErro != e.classe || len(c.Resultados) != e.resultados {
+			t.Errorf("carta %d = %+v, esperado %+v", i, c, e)
+		}
+		if (c.Erro != "") != (e.classe != "") || c.Tentativas < 1 {
+			t.Errorf("carta %d: erro %q, tentativas %d", i, c.Erro, c.Tentativas)
+		}
+	}
+}
+
+func TestScrapeHandlerRequestInvalido(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+
+	casos := map[string]ScrapeRequest{
+		"sem cartas":        {},
+		"modo inválido":     {Cards: []CardInput{charizard}, Modo: "random"},
+		"condição inválida": {Cards: []CardInput{charizard}, Condicoes: []string{"XX"}},
+		"língua inválida":   {Cards: []CardInput{charizard}, Linguas: []string{"klingon"}},
+	}
+	for nome, req := range casos {
+		if status, _ := postScrape(t, req); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, esperado 400", nome, status)
+		}
+	}
+}
+
+func TestMonitorLoop(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	config.MonitorIntervalo = 0
+	config.MonitorVariacao = 1
+
+	monitorMutex.Lock()
+	monitorRunning = true
+	monitorPaused = false
+	monitorMutex.Unlock()
+
+	wgMonitor.Add(1)
+	go monitorLoop(context.Background(), []CardInput{charizard}, OpcoesBusca{Modo: ModoPrimeira})
+
+	var registros []MonitorEntry
+	limite := time.Now().Add(10 * time.Second)
+	for time.Now().Before(limite) {
+		obs, _ := store.Observacoes(FiltroObservacoes{})
+		registros = resumoHistorico(obs)
+		if len(registros) > 0 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	monitorMutex.Lock()
+	monitorRunning = false
+	monitorMutex.Unlock()
+	wgMonitor.Wait()
+
+	if len(registros) != 1 {
+		t.Fatalf("esperava 1 registro de monitoramento, veio %+v", registros)
+	}
+	me := registros[0]
+	if me.Nome != "Charizard ex" || me.PrecoAtual != 350 || me.PrecoInicial != 350 || me.Loja != "Loja Alfa" || me.Estado != "SP" {
+		t.Errorf("registro = %+v", me)
+	}
+}
+
+// Backend fake: responde depois de um atraso que depende da carta
+type scraperAtrasado struct{ atrasos map[string]time.Duration }
+
+func (s scraperAtrasado) Busca(ctx context.Context, card CardInput, opts OpcoesBusca) ([]CardResult, error) {
+	if err := esperar(ctx, s.atrasos[card.Numero]); err != nil {
+		return nil, err
+	}
+	return []CardResult{{Nome: card.Nome, Colecao: card.Colecao, Numero: card.Numero, Preco: 1}}, nil
+}
+
+func (scraperAtrasado) Fecha() {}
+
+func TestScrapeHandlerParaleloPreservaOrdem(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	config.Concorrencia = 3
+
+	// A primeira carta é a mais lenta: em paralelo termina por último
+	fake := scraperAtrasado{atrasos: map[string]time.Duration{
+		"1": 150 * time.Millisecond, "2": 100 * time.Millisecond, "3": 50 * time.Millisecond,
+	}}
+	novoOriginal := novoScraper
+	novoScraper = func() (Scraper, error) { return fake, nil }
+	t.Cleanup(func() { novoScraper = novoOriginal })
+
+	cards := []CardInput{
+		{Nome: "A", Colecao: "X", Numero: "1"},
+		{Nome: "B", Colecao: "X", Numero: "2"},
+		{Nome: "C", Colecao: "X", Numero: "3"},
+	}
+	inicio := time.Now()
+	_, res := postScrape(t, ScrapeRequest{Cards: cards})
+	if d := time.Since(inicio); d >= 300*time.Millisecond {
+		t.Errorf("cartas não rodaram em paralelo (%v)", d)
+	}
+	if len(res) != 3 || res[0].Nome != "A" || res[1].Nome != "B" || res[2].Nome != "C" {
+		t.Errorf("ordem da resposta = %+v", res)
+	}
+}
+
+func TestProcessarCartasCancelamento(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	config.Concorrencia = 1
+	config.TimeoutPorCarta = 0
+
+	fake := scraperAtrasado{atrasos: map[string]time.Duration{"1": time.Minute, "2": time.Minute}}
+	cards := []CardInput{{Nome: "A", Colecao: "X", Numero: "1"}, {Nome: "B", Colecao: "X", Numero: "2"}}
+
+	// Cancelamento: a carta em andamento desiste e a seguinte nem começa
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	concluidas := 0
+	inicio := time.Now()
+	res := processarCartas(ctx, fake, cards, OpcoesBusca{}, nil, func(int, resultadoCarta) { concluidas++ })
+	if d := time.Since(inicio); d > 2*time.Second {
+		t.Fatalf("processarCartas ignorou o cancelamento (%v)", d)
+	}
+	for _, r := range res {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s: err = %v, esperado context.Canceled", r.Card.Nome, r.Err)
+		}
+	}
+	if concluidas != 1 {
+		t.Errorf("aoConcluir chamado %d vezes, esperado 1", concluidas)
+	}
+
+	// Prazo por carta: cada carta estoura o próprio timeout
+	config.TimeoutPorCarta = 20 * time.Millisecond
+	res = processarCartas(context.Background(), fake, cards, OpcoesBusca{}, nil, nil)
+	for _, r := range res {
+		if !errors.Is(r.Err, context.DeadlineExceeded) {
+			t.Errorf("%s: err = %v, esperado context.DeadlineExceeded", r.Card.Nome, r.Err)
+		}
+	}
+}
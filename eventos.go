@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// --------------------------------------------------------------------------------
+// EVENTOS DE PROGRESSO (Server-Sent Events)
+// --------------------------------------------------------------------------------
+
+// Tipos de evento
+const (
+	EventoCartaIniciada     = "card_started"
+	EventoCartaPrecificada  = "card_priced"
+	EventoCartaSemOfertas   = "card_no_offers"
+	EventoCartaFalhou       = "card_failed"
+	EventoChecagemConcluida = "check_completed" // fim de uma checagem do monitor
+	EventoJobFinalizado     = "job_finished"
+)
+
+// Origens dos eventos
+const (
+	OrigemMonitor = "monitor"
+	OrigemJob     = "job"
+)
+
+// Evento publicado pelo monitor e pelos jobs
+type Evento struct {
+	Tipo       string       `json:"tipo"`
+	Origem     string       `json:"origem"`
+	JobID      string       `json:"job_id,omitempty"`
+	Checagem   int          `json:"checagem,omitempty"` // número da checagem do monitor
+	Status     string       `json:"status,omitempty"`   // status final do job
+	Card       *CardInput   `json:"card,omitempty"`
+	Resultados []CardResult `json:"resultados,omitempty"`
+	Erro       string       `json:"erro,omitempty"`
+	ClasseErro string       `json:"classe_erro,omitempty"`
+	Progresso  int          `json:"progresso"` // % concluído da checagem ou do job
+	Momento    time.Time    `json:"momento"`
+}
+
+// Distribui os eventos para os clientes conectados em /events
+type corretorEventos struct {
+	mu        sync.Mutex
+	inscritos map[chan Evento]struct{}
+}
+
+var eventos = &corretorEventos{inscritos: map[chan Evento]struct{}{}}
+
+// Tamanho do buffer de cada cliente; eventos além disso são descartados
+// para um cliente lento não travar o scraping
+const bufferEventosCliente = 64
+
+func (c *corretorEventos) inscrever() chan Evento {
+	ch := make(chan Evento, bufferEventosCliente)
+	c.mu.Lock()
+	c.inscritos[ch] = struct{}{}
+	c.mu.Unlock()
+	return ch
+}
+
+func (c *corretorEventos) desinscrever(ch chan Evento) {
+	c.mu.Lock()
+	delete(c.inscritos, ch)
+	c.mu.Unlock()
+}
+
+// Envia o evento a todos os inscritos sem bloquear
+func (c *corretorEventos) publicar(ev Evento) {
+	if ev.Momento.IsZero() {
+		ev.Momento = time.Now()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ch := range c.inscritos {
+		select {
+		case ch <- ev:
+		default:
+			fmt.Printf("[EVENTOS] Cliente lento, evento %s descartado.\n", ev.Tipo)
+		}
+	}
+}
+
+// Evento de conclusão de uma carta (preço, sem ofertas ou falha)
+func eventoCarta(origem string, r resultadoCarta) Evento {
+	card := r.Card
+	ev := Evento{Tipo: EventoCartaPrecificada, Origem: origem, Card: &card, Resultados: r.Resultados}
+	switch {
+	case r.Err != nil:
+		ev.Tipo, ev.Erro, ev.ClasseErro = EventoCartaFalhou, r.Err.Error(), classificaErro(r.Err)
+	case len(r.Resultados) == 0:
+		ev.Tipo = EventoCartaSemOfertas
+	}
+	return ev
+}
+
+// Intervalo entre comentários de keep-alive no stream
+const intervaloKeepAliveEventos = 15 * time.Second
+
+// GET /events - stream SSE com os eventos do monitor e dos jobs.
+// Filtros opcionais: ?origem=monitor|job e ?job=<id>.
+func eventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Use GET para /events", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+	origem, jobID := r.URL.Query().Get("origem"), r.URL.Query().Get("job")
+
+	ch := eventos.inscrever()
+	defer eventos.desinscrever(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(intervaloKeepAliveEventos)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case ev := <-ch:
+			if (origem != "" && ev.Origem != origem) || (jobID != "" && ev.JobID != jobID) {
+				continue
+			}
+			dados, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Tipo, dados)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventsJob(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	iniciaArmazemJobs(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(eventsHandler))
+	t.Cleanup(srv.Close)
+	resp, err := http.Get(srv.URL + "?origem=job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	id := postJob(t, ScrapeRequest{Cards: []CardInput{charizard}}).ID
+
+	// Lê o stream até o fim do job
+	var tipos []string
+	linhas := make(chan string, 100)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			linhas <- sc.Text()
+		}
+		close(linhas)
+	}()
+	limite := time.After(10 * time.Second)
+leitura:
+	for {
+		select {
+		case l, ok := <-linhas:
+			if !ok {
+				break leitura
+			}
+			if !strings.HasPrefix(l, "data: ") {
+				continue
+			}
+			var ev Evento
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(l, "data: ")), &ev); err != nil {
+				t.Fatalf("evento inválido %q: %v", l, err)
+			}
+			if ev.JobID != id {
+				t.Errorf("evento de outro job: %+v", ev)
+			}
+			tipos = append(tipos, ev.Tipo)
+			if ev.Tipo == EventoJobFinalizado {
+				break leitura
+			}
+		case <-limite:
+			t.Fatalf("timeout; eventos até agora: %v", tipos)
+		}
+	}
+
+	esperado := []string{EventoCartaIniciada, EventoCartaPrecificada, EventoJobFinalizado}
+	if strings.Join(tipos, ",") != strings.Join(esperado, ",") {
+		t.Errorf("eventos = %v, esperado %v", tipos, esperado)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// --------------------------------------------------------------------------------
+// FEED DE MUDANÇAS DE PREÇO (WebSocket)
+// --------------------------------------------------------------------------------
+
+// Mensagem enviada quando o monitor grava um PrecoAtual diferente do anterior
+type MudancaPreco struct {
+	Tipo          string  `json:"tipo"` // sempre "price_change"
+	Nome          string  `json:"nome"`
+	Colecao       string  `json:"colecao"`
+	Numero        string  `json:"numero"`
+	PrecoAnterior float64 `json:"preco_anterior"`
+	PrecoNovo     float64 `json:"preco_novo"`
+	Variacao      float64 `json:"variacao_percentual"` // (novo - anterior) / anterior × 100
+	Loja          string  `json:"loja"`
+	Data          string  `json:"data"`
+}
+
+// Mensagem do cliente: troca o conjunto de cartas acompanhadas
+type inscricaoFeed struct {
+	Cards []CardInput `json:"cards"`
+}
+
+// Confirmação da inscrição, enviada ao cliente
+type confirmacaoFeed struct {
+	Tipo  string      `json:"tipo"` // sempre "subscribed"
+	Cards []CardInput `json:"cards"`
+}
+
+// Identifica a carta sem diferenciar maiúsculas e espaços nas pontas
+func chaveCarta(nome, colecao, numero string) string {
+	norm := func(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+	return norm(nome) + "|" + norm(colecao) + "|" + norm(numero)
+}
+
+// Um cliente conectado e as cartas que ele acompanha
+type clienteFeed struct {
+	envio  chan any
+	mu     sync.Mutex
+	cartas map[string]bool
+}
+
+func (c *clienteFeed) acompanha(chave string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cartas[chave]
+}
+
+// Clientes conectados ao feed
+type feedPrecos struct {
+	mu       sync.Mutex
+	clientes map[*clienteFeed]struct{}
+}
+
+var feedMudancasPreco = &feedPrecos{clientes: map[*clienteFeed]struct{}{}}
+
+// Buffer de mensagens por cliente; um cliente lento perde mudanças em vez
+// de travar o monitor
+const bufferFeedCliente = 32
+
+// Envia a mudança aos clientes inscritos na carta
+func (f *feedPrecos) notificar(m MudancaPreco) {
+	chave := chaveCarta(m.Nome, m.Colecao, m.Numero)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for c := range f.clientes {
+		if !c.acompanha(chave) {
+			continue
+		}
+		select {
+		case c.envio <- m:
+		default:
+			fmt.Printf("[FEED] Cliente lento, mudança de %s descartada.\n", m.Nome)
+		}
+	}
+}
+
+// Registra a mudança de preço de uma carta monitorada no feed
+func notificarMudancaPreco(r CardResult, anterior float64, dataStr string) {
+	variacao := 0.0
+	if anterior != 0 {
+		variacao = (r.Preco - anterior) / anterior * 100
+	}
+	feedMudancasPreco.notificar(MudancaPreco{
+		Tipo:          "price_change",
+		Nome:          r.Nome,
+		Colecao:       r.Colecao,
+		Numero:        r.Numero,
+		PrecoAnterior: anterior,
+		PrecoNovo:     r.Preco,
+		Variacao:      variacao,
+		Loja:          r.Loja,
+		Data:          dataStr,
+	})
+}
+
+// Intervalos do keep-alive da conexão
+const (
+	intervaloPingFeed = 30 * time.Second
+	prazoPongFeed     = 2 * intervaloPingFeed
+	prazoEscritaFeed  = 10 * time.Second
+)
+
+var upgraderFeed = websocket.Upgrader{
+	// O front-end roda em outra origem; a API não usa cookies
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// GET /ws/prices - WebSocket. O cliente envia {"cards":[{nome,colecao,numero}]}
+// (pode reenviar para trocar a lista) e recebe um "price_change" sempre que o
+// monitor grava um preço diferente para uma dessas cartas.
+func wsPricesHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgraderFeed.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade já respondeu com o erro
+	}
+	cliente := &clienteFeed{envio: make(chan any, bufferFeedCliente), cartas: map[string]bool{}}
+	feedMudancasPreco.mu.Lock()
+	feedMudancasPreco.clientes[cliente] = struct{}{}
+	feedMudancasPreco.mu.Unlock()
+
+	fim := make(chan struct{})
+	go escreveFeed(conn, cliente, fim)
+
+	// Leitura: inscrições do cliente até a conexão cair
+	conn.SetReadDeadline(time.Now().Add(prazoPongFeed))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(prazoPongFeed))
+	})
+	for {
+		var insc inscricaoFeed
+		if err := conn.ReadJSON(&insc); err != nil {
+			break
+		}
+		cartas := map[string]bool{}
+		for _, c := range insc.Cards {
+			cartas[chaveCarta(c.Nome, c.Colecao, c.Numero)] = true
+		}
+		cliente.mu.Lock()
+		cliente.cartas = cartas
+		cliente.mu.Unlock()
+		select {
+		case cliente.envio <- confirmacaoFeed{Tipo: "subscribed", Cards: insc.Cards}:
+		default:
+		}
+	}
+
+	feedMudancasPreco.mu.Lock()
+	delete(feedMudancasPreco.clientes, cliente)
+	feedMudancasPreco.mu.Unlock()
+	close(fim)
+	conn.Close()
+}
+
+// Envia as mensagens e os pings do cliente até fim ser fechado
+func escreveFeed(conn *websocket.Conn, cliente *clienteFeed, fim chan struct{}) {
+	ping := time.NewTicker(intervaloPingFeed)
+	defer ping.Stop()
+	for {
+		select {
+		case <-fim:
+			return
+		case msg := <-cliente.envio:
+			conn.SetWriteDeadline(time.Now().Add(prazoEscritaFeed))
+			if err := conn.WriteJSON(msg); err != nil {
+				conn.Close() // derruba a leitura também
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(prazoEscritaFeed)); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
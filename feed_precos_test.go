@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestFeedMudancaPreco(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(wsPricesHandler))
+	t.Cleanup(srv.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteJSON(inscricaoFeed{Cards: []CardInput{{Nome: "charizard ex", Colecao: "OBF", Numero: "125"}}}); err != nil {
+		t.Fatal(err)
+	}
+	var conf confirmacaoFeed
+	if err := conn.ReadJSON(&conf); err != nil || conf.Tipo != "subscribed" {
+		t.Fatalf("confirmação = %+v, err %v", conf, err)
+	}
+
+	configOriginal := config
+	t.Cleanup(func() { config = configOriginal })
+	config.OutputFolder = t.TempDir()
+	iniciaStoreTemporario(t)
+
+	r := CardResult{Nome: "Charizard ex", Colecao: "OBF", Numero: "125", Preco: 200, PrecoTotal: 200, Loja: "Loja Alfa"}
+	cara := CardResult{Nome: "Charizard ex", Colecao: "OBF", Numero: "125", Preco: 260, PrecoTotal: 260, Loja: "Loja Beta"}
+	outra := CardResult{Nome: "Pikachu", Colecao: "SVP", Numero: "27", Preco: 10}
+	salvarMonitoramento([]CardResult{r, cara}, "c1", "2024-01-01 10:00:00") // primeiro registro: sem mudança
+	salvarMonitoramento([]CardResult{outra}, "c1", "2024-01-01 10:00:00")
+	salvarMonitoramento([]CardResult{cara, r}, "c2", "2024-01-01 11:00:00") // mesmas ofertas em outra ordem: sem mudança
+	outra.Preco = 12
+	salvarMonitoramento([]CardResult{outra}, "c2", "2024-01-01 11:00:00") // carta não inscrita
+	r.Preco, r.PrecoTotal = 150, 150
+	salvarMonitoramento([]CardResult{cara, r}, "c3", "2024-01-01 12:00:00")
+
+	var m MudancaPreco
+	if err := conn.ReadJSON(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Tipo != "price_change" || m.Nome != "Charizard ex" || m.PrecoAnterior != 200 || m.PrecoNovo != 150 || math.Abs(m.Variacao+25) > 1e-9 {
+		t.Errorf("mudança = %+v", m)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// --------------------------------------------------------------------------------
+// MARKETPLACE DE FIXTURES (substituto offline do site da Liga)
+// --------------------------------------------------------------------------------
+
+// Pasta com as páginas gravadas, uma por carta: <ed>_<num>.html
+var pastaFixturesLiga = filepath.Join("testdata", "liga")
+
+// Serve as páginas de carta gravadas em testdata/liga. Cartas sem arquivo
+// respondem 404, como uma carta inexistente.
+func handlerMarketplaceFixture(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("view") != "cards/card" {
+		http.NotFound(w, r)
+		return
+	}
+	nomeArq := filepath.Base(q.Get("ed") + "_" + q.Get("num") + ".html")
+	html, err := os.ReadFile(filepath.Join(pastaFixturesLiga, nomeArq))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(html)
+}
+
+// Sobe o marketplace de fixtures e aponta a config para ele. Saídas vão para
+// uma pasta temporária e a config original é restaurada ao fim do teste.
+func iniciaMarketplaceFixture(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(handlerMarketplaceFixture))
+	t.Cleanup(srv.Close)
+
+	configOriginal := config
+	t.Cleanup(func() { config = configOriginal })
+
+	config.Website = srv.URL + "/"
+	config.Backend = "http"
+	config.OutputFolder = t.TempDir()
+	config.FretePorLoja = map[string]float64{}
+	config.FretePorEstado = map[string]float64{"RJ": 15}
+	config.ArquivoSeletores = "" // perfil embutido
+	config.IntervaloPorHost = 0
+	config.Retry = map[string]PoliticaRetry{
+		ClasseRede:    {Tentativas: 3, Base: time.Millisecond, Max: 5 * time.Millisecond},
+		ClasseTimeout: {Tentativas: 2, Base: time.Millisecond, Max: 5 * time.Millisecond},
+		ClasseSeletor: {Tentativas: 2, Base: time.Millisecond, Max: 5 * time.Millisecond},
+	}
+	iniciaStoreTemporario(t)
+	return srv
+}
+
+// Abre um banco vazio na OutputFolder e o instala como store global
+func iniciaStoreTemporario(t *testing.T) Store {
+	t.Helper()
+	st, err := abrirSQLiteStore(filepath.Join(config.OutputFolder, "teste.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := store
+	store = st
+	t.Cleanup(func() {
+		store = original
+		st.Fechar()
+	})
+	return st
+}
+
+// O banner de cookies e o modal do carrinho da fixture batem com o perfil
+// padrão, e o item do carrinho tem os dados que o fallback do Selenium lê
+func TestFixtureBannerECarrinho(t *testing.T) {
+	f, err := os.Open(filepath.Join(pastaFixturesLiga, "OBF_125.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := perfilSeletoresPadrao
+
+	for _, c := range checaSeletores(doc, p) {
+		if !c.Encontrado {
+			t.Errorf("seletor %s (%q) não existe na fixture", c.Campo, c.Seletor)
+		}
+	}
+
+	// Mesma busca de localizaItemCarrinho + extraiDadosItemCarrinho
+	var linha *goquery.Selection
+	doc.Find(p.ItensCarrinho).Find(p.LinhaCarrinho).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		titulo := s.Find(p.TituloItemCarrinho).Text()
+		if strings.Contains(titulo, charizard.Nome) && strings.Contains(titulo, "("+charizard.Numero+")") {
+			linha = s
+		}
+		return linha == nil
+	})
+	if linha == nil {
+		t.Fatal("item do carrinho não encontrado")
+	}
+	if q := parseEstoque(linha.Find(p.EstoqueItemCarrinho).Text()); q != 2 {
+		t.Errorf("estoque no carrinho = %d", q)
+	}
+	if preco := convertePrecoParaFloat(linha.Find(p.PrecoItemCarrinho).Text()); preco != 350 {
+		t.Errorf("preço no carrinho = %.2f", preco)
+	}
+	if linha.Find(p.RemoverItemCarrinho).Length() == 0 {
+		t.Error("item do carrinho sem botão de remover")
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import "strings"
+
+// --------------------------------------------------------------------------------
+// FRETE E PREÇO TOTAL
+// --------------------------------------------------------------------------------
+
+// Interpreta o texto de frete da listagem ("R$ 12,50", "Frete grátis").
+// Retorna false quando a loja não informa o frete.
+func parseFrete(txt string) (float64, bool) {
+	up := semAcento.Replace(strings.ToUpper(strings.TrimSpace(txt)))
+	if up == "" {
+		return 0, false
+	}
+	if strings.Contains(up, "GRATIS") {
+		return 0, true
+	}
+	// Ignora prefixos como "Frete:" antes do valor
+	i := strings.IndexAny(txt, "0123456789")
+	if i < 0 {
+		return 0, false
+	}
+	val := convertePrecoParaFloat(txt[i:])
+	if val <= 0 {
+		return 0, false
+	}
+	return val, true
+}
+
+// Estimativa de frete pela tabela da config: loja (ID ou nome) tem prioridade
+// sobre o estado. Retorna false se não houver entrada.
+func freteDaTabela(r CardResult) (float64, bool) {
+	for _, chave := range []string{r.LojaID, r.Loja} {
+		if chave == "" {
+			continue
+		}
+		if v, ok := config.FretePorLoja[chave]; ok {
+			return v, true
+		}
+	}
+	if r.Estado != "" {
+		if v, ok := config.FretePorEstado[r.Estado]; ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// Define Frete e PrecoTotal (preço × quantidade pedida + frete).
+// txtFrete é o que a listagem mostra; sem ele, usa a tabela da config.
+func aplicaPrecoTotal(r *CardResult, card CardInput, txtFrete string) {
+	qtd := card.Quantidade
+	if qtd <= 0 {
+		qtd = 1
+	}
+	frete, ok := parseFrete(txtFrete)
+	if !ok {
+		frete, _ = freteDaTabela(*r)
+	}
+	r.QuantidadePedida = qtd
+	r.Frete = frete
+	r.PrecoTotal = r.Preco*float64(qtd) + frete
+}
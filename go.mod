@@ -2,6 +2,25 @@ module go_project
 
 go 1.24.0
 
-require github.com/tebeka/selenium v0.9.9
+require (
+	github.com/PuerkitoBio/goquery v1.10.3
+	github.com/gorilla/websocket v1.5.3
+	github.com/tebeka/selenium v0.9.9
+	modernc.org/sqlite v1.38.0
+)
 
-require github.com/blang/semver v3.5.1+incompatible // indirect
+require (
+	github.com/andybalholm/cascadia v1.3.3 // indirect
+	github.com/blang/semver v3.5.1+incompatible // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/exp v0.0.0-20250408133849-7e4ce0ab07d0 // indirect
+	golang.org/x/net v0.39.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	modernc.org/libc v1.65.10 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
+)
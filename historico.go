@@ -0,0 +1,489 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --------------------------------------------------------------------------------
+// HISTÓRICO DE OBSERVAÇÕES DO MONITOR (append-only)
+// --------------------------------------------------------------------------------
+
+// Uma oferta vista numa checagem do monitor. O histórico só recebe linhas
+// novas; o resumo do monitor (preço inicial/atual) é derivado dele.
+type Observacao struct {
+	Data        string   `json:"data"`     // "2006-01-02 15:04:05"
+	Checagem    string   `json:"checagem"` // ID da checagem do monitor
+	Nome        string   `json:"nome"`
+	Colecao     string   `json:"colecao"`
+	Numero      string   `json:"numero"`
+	Loja        string   `json:"loja"`
+	LojaID      string   `json:"loja_id"`
+	Estado      string   `json:"estado"`
+	Condicao    Condicao `json:"condicao"`
+	Lingua      string   `json:"lingua"`
+	Preco       float64  `json:"preco"`
+	PrecoTotal  float64  `json:"preco_total"`
+	Quantidade  int      `json:"quantidade"`  // estoque da loja
+	Selecionada bool     `json:"selecionada"` // oferta que define o preço da carta na checagem
+}
+
+// Colunas do CSV de histórico
+var colunasHistoricoCSV = []string{
+	"data", "checagem", "nome", "colecao", "numero",
+	"loja", "loja_id", "estado", "condicao", "lingua",
+	"preco", "preco_total", "quantidade", "selecionada",
+}
+
+// Checagem atribuída às observações migradas do CSV de resumo antigo
+const checagemLegado = "legado"
+
+func novaObservacao(r CardResult, checagem, dataStr string, selecionada bool) Observacao {
+	return Observacao{
+		Data:        dataStr,
+		Checagem:    checagem,
+		Nome:        r.Nome,
+		Colecao:     r.Colecao,
+		Numero:      r.Numero,
+		Loja:        r.Loja,
+		LojaID:      r.LojaID,
+		Estado:      r.Estado,
+		Condicao:    r.Condicao,
+		Lingua:      r.Lingua,
+		Preco:       r.Preco,
+		PrecoTotal:  r.PrecoTotal,
+		Quantidade:  r.Quantidade,
+		Selecionada: selecionada,
+	}
+}
+
+// Escreve as observações em CSV (separador ';'), com ou sem cabeçalho
+func escreverHistoricoCSV(w io.Writer, obs []Observacao, cabecalho bool) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+	if cabecalho {
+		if err := writer.Write(colunasHistoricoCSV); err != nil {
+			return err
+		}
+	}
+	for _, o := range obs {
+		err := writer.Write([]string{
+			o.Data,
+			o.Checagem,
+			o.Nome,
+			o.Colecao,
+			o.Numero,
+			o.Loja,
+			o.LojaID,
+			o.Estado,
+			string(o.Condicao),
+			o.Lingua,
+			fmt.Sprintf("%.2f", o.Preco),
+			fmt.Sprintf("%.2f", o.PrecoTotal),
+			strconv.Itoa(o.Quantidade),
+			strconv.FormatBool(o.Selecionada),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Lê o histórico inteiro (vazio se o arquivo não existe)
+func carregarHistoricoCSV(caminho string) ([]Observacao, error) {
+	f, err := os.Open(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return lerHistoricoCSV(f, true)
+}
+
+// Lê observações em CSV. Sem cabeçalho, r é um trecho do meio do arquivo
+// e as colunas são as de colunasHistoricoCSV.
+func lerHistoricoCSV(r io.Reader, cabecalho bool) ([]Observacao, error) {
+	if !cabecalho {
+		r = io.MultiReader(strings.NewReader(strings.Join(colunasHistoricoCSV, ";")+"\n"), r)
+	}
+	reader := csv.NewReader(r)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1 // linha cortada por um crash é só ignorada
+	cols, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	colIndex := make(map[string]int)
+	for i, c := range cols {
+		colIndex[strings.ToLower(strings.TrimSpace(c))] = i
+	}
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var lista []Observacao
+	for _, line := range lines {
+		if len(line) < len(cols) {
+			continue
+		}
+		campo := func(nome string) string {
+			if i, ok := colIndex[nome]; ok {
+				return line[i]
+			}
+			return ""
+		}
+		o := Observacao{
+			Data:     campo("data"),
+			Checagem: campo("checagem"),
+			Nome:     campo("nome"),
+			Colecao:  campo("colecao"),
+			Numero:   campo("numero"),
+			Loja:     campo("loja"),
+			LojaID:   campo("loja_id"),
+			Estado:   campo("estado"),
+			Condicao: Condicao(campo("condicao")),
+			Lingua:   campo("lingua"),
+		}
+		o.Preco, _ = strconv.ParseFloat(campo("preco"), 64)
+		o.PrecoTotal, _ = strconv.ParseFloat(campo("preco_total"), 64)
+		o.Quantidade, _ = strconv.Atoi(campo("quantidade"))
+		o.Selecionada, _ = strconv.ParseBool(campo("selecionada"))
+		lista = append(lista, o)
+	}
+	return lista, nil
+}
+
+// Resumo por carta (na ordem em que apareceram): preço inicial da primeira
+// observação selecionada e preço atual/loja da última
+func resumoHistorico(obs []Observacao) []MonitorEntry {
+	var r resumoCartas
+	r.adicionar(obs)
+	return r.entradas
+}
+
+// Resumo montado observação a observação, na ordem do histórico
+type resumoCartas struct {
+	entradas []MonitorEntry
+	indice   map[string]int // chaveCarta -> posição em entradas
+}
+
+func (r *resumoCartas) adicionar(obs []Observacao) {
+	if r.indice == nil {
+		r.indice = map[string]int{}
+	}
+	for _, o := range obs {
+		if !o.Selecionada {
+			continue
+		}
+		chave := chaveCarta(o.Nome, o.Colecao, o.Numero)
+		i, ok := r.indice[chave]
+		if !ok {
+			i = len(r.entradas)
+			r.indice[chave] = i
+			r.entradas = append(r.entradas, MonitorEntry{
+				Nome:         o.Nome,
+				Colecao:      o.Colecao,
+				Numero:       o.Numero,
+				PrecoInicial: o.Preco,
+				DataInicial:  o.Data,
+			})
+		}
+		me := &r.entradas[i]
+		me.PrecoAtual, me.DataAtual = o.Preco, o.Data
+		me.Loja, me.LojaID, me.Estado = o.Loja, o.LojaID, o.Estado
+	}
+}
+
+// Último preço selecionado da carta
+func (r *resumoCartas) ultimoPreco(nome, colecao, numero string) (float64, bool) {
+	i, ok := r.indice[chaveCarta(nome, colecao, numero)]
+	if !ok {
+		return 0, false
+	}
+	return r.entradas[i].PrecoAtual, true
+}
+
+// Resumo do histórico mantido em memória pelos stores de arquivo. Cada
+// atualização lê só as linhas anexadas desde a anterior (por este ou por
+// outro processo), então o histórico é lido inteiro uma vez só.
+type resumoIncremental struct {
+	resumoCartas
+	lidoAte int64 // bytes do arquivo já incluídos no resumo
+}
+
+// Inclui as linhas completas anexadas ao arquivo desde a última chamada.
+// ler recebe o trecho novo e se ele começa no início do arquivo. Chamar
+// com a trava do arquivo.
+func (r *resumoIncremental) atualizar(caminho string, ler func(trecho io.Reader, inicio bool) ([]Observacao, error)) error {
+	f, err := os.Open(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		*r = resumoIncremental{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < r.lidoAte {
+		*r = resumoIncremental{} // arquivo recriado ou truncado
+	}
+	trecho := make([]byte, info.Size()-r.lidoAte)
+	if _, err := f.ReadAt(trecho, r.lidoAte); err != nil {
+		return err
+	}
+	// Uma linha final sem '\n' (gravação em andamento ou cortada) fica para depois
+	fim := bytes.LastIndexByte(trecho, '\n') + 1
+	if fim == 0 {
+		return nil
+	}
+	obs, err := ler(bytes.NewReader(trecho[:fim]), r.lidoAte == 0)
+	if err != nil {
+		return err
+	}
+	r.adicionar(obs)
+	r.lidoAte += int64(fim)
+	return nil
+}
+
+// Converte o CSV de resumo antigo (só preço inicial e atual) em observações,
+// para o histórico novo não começar do zero
+func migrarResumoLegado(caminhoResumo string) []Observacao {
+	antigos, err := carregarMonitorCSV(caminhoResumo)
+	if err != nil {
+		return nil
+	}
+	var obs []Observacao
+	for _, me := range antigos {
+		base := Observacao{
+			Checagem: checagemLegado, Nome: me.Nome, Colecao: me.Colecao, Numero: me.Numero,
+			Loja: me.Loja, LojaID: me.LojaID, Estado: me.Estado, Selecionada: true,
+		}
+		inicial := base
+		inicial.Data, inicial.Preco = me.DataInicial, me.PrecoInicial
+		obs = append(obs, inicial)
+		if me.DataAtual != me.DataInicial {
+			atual := base
+			atual.Data, atual.Preco = me.DataAtual, me.PrecoAtual
+			obs = append(obs, atual)
+		}
+	}
+	if len(obs) > 0 {
+		fmt.Printf("[INFO] %d cartas do resumo antigo migradas para o histórico.\n", len(antigos))
+	}
+	return obs
+}
+
+// --------------------------------------------------------------------------------
+// GET /history - consulta do histórico por carta e período
+// --------------------------------------------------------------------------------
+
+// Intervalos aceitos em ?interval=
+const (
+	IntervaloHora   = "hour"
+	IntervaloDia    = "day"
+	IntervaloSemana = "week" // semanas começam na segunda-feira
+	IntervaloMes    = "month"
+)
+
+// Preços de uma carta num intervalo, a partir das observações selecionadas
+// (o preço da carta em cada checagem)
+type IntervaloPreco struct {
+	Inicio     string  `json:"inicio"` // "2006-01-02 15:04:05"
+	Abertura   float64 `json:"abertura"`
+	Maxima     float64 `json:"maxima"`
+	Minima     float64 `json:"minima"`
+	Fechamento float64 `json:"fechamento"`
+	Media      float64 `json:"media"`
+	Amostras   int     `json:"amostras"`
+}
+
+type HistoricoCarta struct {
+	Nome        string           `json:"nome"`
+	Colecao     string           `json:"colecao"`
+	Numero      string           `json:"numero"`
+	Observacoes []Observacao     `json:"observacoes,omitempty"` // sem ?interval=
+	Intervalos  []IntervaloPreco `json:"intervalos,omitempty"`  // com ?interval=
+}
+
+type RespostaHistorico struct {
+	Intervalo string           `json:"intervalo,omitempty"`
+	Cartas    []HistoricoCarta `json:"cartas"`
+}
+
+// Converte ?from=/?to= para o formato de Observacao.Data. Aceita data
+// ("2006-01-02"), data e hora ou RFC 3339; só com a data, ?to= inclui o dia todo.
+func limiteHistorico(valor string, fimDoDia bool) (string, error) {
+	if valor == "" {
+		return "", nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", valor, time.Local); err == nil {
+		if fimDoDia {
+			t = t.AddDate(0, 0, 1).Add(-time.Second) // o dia pode ter 23h ou 25h (horário de verão)
+		}
+		return t.Format("2006-01-02 15:04:05"), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", valor, time.Local); err == nil {
+		return t.Format("2006-01-02 15:04:05"), nil
+	}
+	if t, err := time.Parse(time.RFC3339, valor); err == nil {
+		return t.In(time.Local).Format("2006-01-02 15:04:05"), nil
+	}
+	return "", fmt.Errorf("data inválida %q (use 2006-01-02, \"2006-01-02 15:04:05\" ou RFC 3339)", valor)
+}
+
+// Início do intervalo que contém t
+func inicioIntervalo(t time.Time, intervalo string) time.Time {
+	ano, mes, dia := t.Date()
+	switch intervalo {
+	case IntervaloHora:
+		return time.Date(ano, mes, dia, t.Hour(), 0, 0, 0, t.Location())
+	case IntervaloSemana:
+		desde := (int(t.Weekday()) + 6) % 7 // dias desde segunda
+		return time.Date(ano, mes, dia-desde, 0, 0, 0, 0, t.Location())
+	case IntervaloMes:
+		return time.Date(ano, mes, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(ano, mes, dia, 0, 0, 0, 0, t.Location())
+}
+
+// Agrupa os preços selecionados em intervalos (OHLC, mínima, máxima e média),
+// em ordem cronológica. Observações com data ilegível são ignoradas.
+func agregarHistorico(obs []Observacao, intervalo string) []IntervaloPreco {
+	type amostra struct {
+		quando time.Time
+		preco  float64
+	}
+	var amostras []amostra
+	for _, o := range obs {
+		if !o.Selecionada {
+			continue
+		}
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", o.Data, time.Local)
+		if err != nil {
+			continue
+		}
+		amostras = append(amostras, amostra{t, o.Preco})
+	}
+	sort.SliceStable(amostras, func(i, k int) bool { return amostras[i].quando.Before(amostras[k].quando) })
+
+	var lista []IntervaloPreco
+	var inicioAtual time.Time
+	soma := 0.0
+	for _, a := range amostras {
+		inicio := inicioIntervalo(a.quando, intervalo)
+		if len(lista) == 0 || !inicio.Equal(inicioAtual) {
+			inicioAtual, soma = inicio, 0
+			lista = append(lista, IntervaloPreco{
+				Inicio:   inicio.Format("2006-01-02 15:04:05"),
+				Abertura: a.preco, Maxima: a.preco, Minima: a.preco,
+			})
+		}
+		ip := &lista[len(lista)-1]
+		ip.Maxima = max(ip.Maxima, a.preco)
+		ip.Minima = min(ip.Minima, a.preco)
+		ip.Fechamento = a.preco
+		ip.Amostras++
+		soma += a.preco
+		ip.Media = math.Round(soma/float64(ip.Amostras)*100) / 100
+	}
+	return lista
+}
+
+// GET /history?nome=&colecao=&numero=&from=&to=&interval=
+// Sem nome, colecao e numero, traz todas as cartas. Sem interval, devolve as observações
+// (todas as ofertas vistas); com interval, os preços agregados por período.
+func historyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Use GET para /history", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	filtro := FiltroObservacoes{
+		Nome:    strings.TrimSpace(q.Get("nome")),
+		Colecao: strings.TrimSpace(q.Get("colecao")),
+		Numero:  strings.TrimSpace(q.Get("numero")),
+	}
+	// A carta é identificada pelos três campos juntos
+	informados := 0
+	for _, campo := range []string{filtro.Nome, filtro.Colecao, filtro.Numero} {
+		if campo != "" {
+			informados++
+		}
+	}
+	if informados != 0 && informados != 3 {
+		http.Error(w, "informe nome, colecao e numero juntos (ou nenhum deles)", http.StatusBadRequest)
+		return
+	}
+	var err error
+	if filtro.De, err = limiteHistorico(q.Get("from"), false); err != nil {
+		http.Error(w, "from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filtro.Ate, err = limiteHistorico(q.Get("to"), true); err != nil {
+		http.Error(w, "to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filtro.De != "" && filtro.Ate != "" && filtro.De > filtro.Ate {
+		http.Error(w, "from depois de to", http.StatusBadRequest)
+		return
+	}
+	intervalo := q.Get("interval")
+	switch intervalo {
+	case "", IntervaloHora, IntervaloDia, IntervaloSemana, IntervaloMes:
+	default:
+		http.Error(w, fmt.Sprintf("interval inválido %q (use %s, %s, %s ou %s)",
+			intervalo, IntervaloHora, IntervaloDia, IntervaloSemana, IntervaloMes), http.StatusBadRequest)
+		return
+	}
+
+	obs, err := store.Observacoes(filtro)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Uma entrada por carta, na ordem em que apareceram no histórico
+	resposta := RespostaHistorico{Intervalo: intervalo, Cartas: []HistoricoCarta{}}
+	porCarta := map[string][]Observacao{}
+	for _, o := range obs {
+		chave := chaveCarta(o.Nome, o.Colecao, o.Numero)
+		if _, ok := porCarta[chave]; !ok {
+			resposta.Cartas = append(resposta.Cartas, HistoricoCarta{Nome: o.Nome, Colecao: o.Colecao, Numero: o.Numero})
+		}
+		porCarta[chave] = append(porCarta[chave], o)
+	}
+	for i := range resposta.Cartas {
+		hc := &resposta.Cartas[i]
+		lista := porCarta[chaveCarta(hc.Nome, hc.Colecao, hc.Numero)]
+		if intervalo == "" {
+			hc.Observacoes = lista
+		} else {
+			hc.Intervalos = agregarHistorico(lista, intervalo)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resposta)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistoricoMonitoramento(t *testing.T) {
+	configOriginal := config
+	t.Cleanup(func() { config = configOriginal })
+	config.OutputFolder = t.TempDir()
+	st := iniciaStoreTemporario(t)
+
+	// Resumo de uma versão antiga, sem histórico: vira as primeiras observações
+	os.WriteFile(filepath.Join(config.OutputFolder, config.MonitorCSV), []byte("nome;colecao;numero;preco_atual;data_atual;preco_inicial;data_inicial\n"+
+		"Charizard ex;OBF;125;300.00;2024-01-02 10:00:00;280.00;2024-01-01 10:00:00\n"), 0644)
+	if err := importarArquivosLegados(st); err != nil {
+		t.Fatal(err)
+	}
+
+	// Modo "all": as ofertas vêm na ordem da página, e a mais barata é a segunda
+	alfa := CardResult{Nome: "Charizard ex", Colecao: "OBF", Numero: "125", Preco: 350, PrecoTotal: 350, Loja: "Loja Alfa", Condicao: CondicaoNM}
+	beta := CardResult{Nome: "Charizard ex", Colecao: "OBF", Numero: "125", Preco: 320, PrecoTotal: 320, Loja: "Loja Beta", Condicao: CondicaoNM}
+	if err := salvarMonitoramento([]CardResult{alfa, beta}, "m-1", "2024-01-03 10:00:00"); err != nil {
+		t.Fatal(err)
+	}
+	if preco, _, _ := st.UltimoPreco("Charizard ex", "OBF", "125"); preco != 320 {
+		t.Errorf("preço da checagem m-1 = %v, esperado o da Loja Beta", preco)
+	}
+	alfa.Preco, alfa.PrecoTotal = 340, 340
+	if err := salvarMonitoramento([]CardResult{alfa}, "m-2", "2024-01-04 10:00:00"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Importar de novo não duplica nada: o store já tem histórico
+	if err := importarArquivosLegados(st); err != nil {
+		t.Fatal(err)
+	}
+
+	hist, err := st.Observacoes(FiltroObservacoes{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 migradas + 2 ofertas da m-1 + 1 da m-2, nada sobrescrito
+	if len(hist) != 5 {
+		t.Fatalf("histórico com %d observações: %+v", len(hist), hist)
+	}
+	if hist[0].Checagem != checagemLegado || hist[0].Preco != 280 {
+		t.Errorf("migração = %+v", hist[0])
+	}
+	if o := hist[2]; o.Checagem != "m-1" || o.Loja != "Loja Alfa" || o.Selecionada {
+		t.Errorf("oferta não selecionada = %+v", o)
+	}
+	if o := hist[3]; o.Checagem != "m-1" || o.Loja != "Loja Beta" || !o.Selecionada {
+		t.Errorf("oferta selecionada = %+v", o)
+	}
+	if filtrado, _ := st.Observacoes(FiltroObservacoes{Nome: "charizard EX", Colecao: "obf", Numero: "125", De: "2024-01-03 00:00:00"}); len(filtrado) != 3 {
+		t.Errorf("filtro por carta e data = %+v", filtrado)
+	}
+
+	resumo := resumoHistorico(hist)
+	if len(resumo) != 1 {
+		t.Fatalf("resumo = %+v", resumo)
+	}
+	me := resumo[0]
+	if me.PrecoInicial != 280 || me.DataInicial != "2024-01-01 10:00:00" || me.PrecoAtual != 340 || me.DataAtual != "2024-01-04 10:00:00" || me.Loja != "Loja Alfa" {
+		t.Errorf("resumo = %+v", me)
+	}
+
+	// O resumo exportado tem o mesmo formato do CSV antigo
+	rec := httptest.NewRecorder()
+	exportHandler(rec, httptest.NewRequest(http.MethodGet, "/export?tipo=monitor", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Charizard ex;OBF;125;340.00") {
+		t.Errorf("GET /export?tipo=monitor: status %d\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHistoryHandler(t *testing.T) {
+	configOriginal := config
+	t.Cleanup(func() { config = configOriginal })
+	config.OutputFolder = t.TempDir()
+	st := iniciaStoreTemporario(t)
+
+	obs := func(data string, preco float64, selecionada bool) Observacao {
+		return Observacao{Data: data, Nome: "Charizard ex", Colecao: "OBF", Numero: "125", Loja: "Loja Alfa", Preco: preco, Selecionada: selecionada}
+	}
+	st.AnexarObservacoes([]Observacao{
+		obs("2024-01-01 10:00:00", 300, true),
+		obs("2024-01-01 10:00:00", 290, false), // outra loja: fora da agregação
+		obs("2024-01-01 14:00:00", 320, true),
+		obs("2024-01-01 18:00:00", 280, true),
+		obs("2024-01-02 10:00:00", 310, true),
+		{Data: "2024-01-01 10:00:00", Nome: "Pikachu", Colecao: "SVP", Numero: "27", Preco: 10, Selecionada: true},
+	})
+
+	consulta := func(query string) (int, RespostaHistorico) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		historyHandler(rec, httptest.NewRequest(http.MethodGet, "/history?"+query, nil))
+		var resp RespostaHistorico
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	// Sem intervalo: as observações, agrupadas por carta
+	code, resp := consulta("")
+	if code != http.StatusOK || len(resp.Cartas) != 2 || len(resp.Cartas[0].Observacoes) != 5 || len(resp.Cartas[1].Observacoes) != 1 {
+		t.Fatalf("status %d, resposta %+v", code, resp)
+	}
+
+	code, resp = consulta("nome=charizard+ex&colecao=OBF&numero=125&from=2024-01-01&to=2024-01-01&interval=day")
+	if code != http.StatusOK || len(resp.Cartas) != 1 || len(resp.Cartas[0].Intervalos) != 1 {
+		t.Fatalf("status %d, resposta %+v", code, resp)
+	}
+	esperado := IntervaloPreco{Inicio: "2024-01-01 00:00:00", Abertura: 300, Maxima: 320, Minima: 280, Fechamento: 280, Media: 300, Amostras: 3}
+	if ip := resp.Cartas[0].Intervalos[0]; ip != esperado {
+		t.Errorf("intervalo = %+v\nesperado %+v", ip, esperado)
+	}
+
+	if _, resp = consulta("nome=Charizard+ex&colecao=OBF&numero=125&interval=hour"); len(resp.Cartas[0].Intervalos) != 4 {
+		t.Errorf("por hora = %+v", resp.Cartas[0].Intervalos)
+	}
+	if _, resp = consulta("nome=Charizard+ex&colecao=OBF&numero=125&interval=week"); len(resp.Cartas[0].Intervalos) != 1 || resp.Cartas[0].Intervalos[0].Inicio != "2024-01-01 00:00:00" {
+		t.Errorf("por semana = %+v", resp.Cartas[0].Intervalos)
+	}
+	if _, resp = consulta("from=2024-01-02+00:00:00"); len(resp.Cartas) != 1 || len(resp.Cartas[0].Observacoes) != 1 {
+		t.Errorf("from com hora = %+v", resp)
+	}
+
+	for _, q := range []string{"interval=year", "from=ontem", "from=2024-01-03&to=2024-01-01", "colecao=OBF", "nome=Charizard+ex", "nome=Charizard+ex&colecao=OBF"} {
+		if code, _ := consulta(q); code != http.StatusBadRequest {
+			t.Errorf("?%s: status %d", q, code)
+		}
+	}
+}
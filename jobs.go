@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --------------------------------------------------------------------------------
+// JOBS ASSÍNCRONOS DE SCRAPING
+// --------------------------------------------------------------------------------
+
+// Estados de um job
+const (
+	JobPendente  = "pending"
+	JobRodando   = "running"
+	JobConcluido = "done"
+	JobCancelado = "canceled"
+	JobFalhou    = "failed" // não foi possível iniciar o scraper
+)
+
+// Situação de cada carta dentro do job
+type CartaJob struct {
+	Card       CardInput    `json:"card"`
+	Concluida  bool         `json:"concluida"`
+	Resultados []CardResult `json:"resultados,omitempty"`
+	Erro       string       `json:"erro,omitempty"`
+	ClasseErro string       `json:"classe_erro,omitempty"`
+	Tentativas int          `json:"tentativas,omitempty"`
+}
+
+// Job como fica gravado no arquivo de jobs
+type Job struct {
+	ID           string        `json:"id"`
+	Status       string        `json:"status"`
+	Request      ScrapeRequest `json:"request"`
+	Cartas       []CartaJob    `json:"cartas"`
+	Erro         string        `json:"erro,omitempty"`
+	CriadoEm     time.Time     `json:"criado_em"`
+	AtualizadoEm time.Time     `json:"atualizado_em"`
+}
+
+func (j *Job) finalizado() bool {
+	return j.Status == JobConcluido || j.Status == JobCancelado || j.Status == JobFalhou
+}
+
+// Resposta de GET /jobs/{id}: progresso e resultados parciais
+type StatusJob struct {
+	ID           string       `json:"id"`
+	Status       string       `json:"status"`
+	Progresso    int          `json:"progresso"` // % das cartas concluídas
+	Total        int          `json:"total"`
+	Concluidas   int          `json:"concluidas"`
+	Resultados   []CardResult `json:"resultados"`
+	Erros        []string     `json:"erros,omitempty"`
+	Erro         string       `json:"erro,omitempty"`
+	CriadoEm     time.Time    `json:"criado_em"`
+	AtualizadoEm time.Time    `json:"atualizado_em"`
+}
+
+func (j *Job) status() StatusJob {
+	st := StatusJob{
+		ID:           j.ID,
+		Status:       j.Status,
+		Total:        len(j.Cartas),
+		Resultados:   []CardResult{},
+		Erro:         j.Erro,
+		CriadoEm:     j.CriadoEm,
+		AtualizadoEm: j.AtualizadoEm,
+	}
+	for _, c := range j.Cartas {
+		if !c.Concluida {
+			continue
+		}
+		st.Concluidas++
+		st.Resultados = append(st.Resultados, c.Resultados...)
+		if c.Erro != "" {
+			st.Erros = append(st.Erros, fmt.Sprintf("%s (%s - %s): %s após %d tentativa(s): %s", c.Card.Nome, c.Card.Colecao, c.Card.Numero, c.ClasseErro, c.Tentativas, c.Erro))
+		}
+	}
+	st.Progresso = j.progresso()
+	return st
+}
+
+// % das cartas concluídas
+func (j *Job) progresso() int {
+	if len(j.Cartas) == 0 {
+		return 0
+	}
+	feitas := 0
+	for _, c := range j.Cartas {
+		if c.Concluida {
+			feitas++
+		}
+	}
+	return feitas * 100 / len(j.Cartas)
+}
+
+// Mantém os jobs em memória e grava cada mudança no store, para que o
+// estado e os resultados parciais sobrevivam a um restart
+type armazemJobs struct {
+	store Store
+
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	cancelar map[string]context.CancelFunc // jobs em execução
+	wg       sync.WaitGroup
+}
+
+// Armazém usado pelos handlers, aberto no main
+var armazemDeJobs *armazemJobs
+
+var errJobFinalizado = errors.New("job já finalizado")
+
+// Carrega os jobs gravados no store
+func abrirArmazemJobs(st Store) (*armazemJobs, error) {
+	a := &armazemJobs{
+		store:    st,
+		jobs:     map[string]*Job{},
+		cancelar: map[string]context.CancelFunc{},
+	}
+	lista, err := st.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range lista {
+		a.jobs[j.ID] = j
+	}
+	return a, nil
+}
+
+// Grava o job no store. Chamar com a.mu travado.
+func (a *armazemJobs) salvar(job *Job) error {
+	if err := a.store.SalvarJob(job); err != nil {
+		fmt.Printf("[JOBS] ERRO ao gravar job %s: %v\n", job.ID, err)
+		return err
+	}
+	return nil
+}
+
+// Registra um job novo (pendente) para o request já validado
+func (a *armazemJobs) criar(req ScrapeRequest) (*Job, error) {
+	id, err := novoIDJob()
+	if err != nil {
+		return nil, err
+	}
+	agora := time.Now()
+	job := &Job{ID: id, Status: JobPendente, Request: req, CriadoEm: agora, AtualizadoEm: agora}
+	for _, c := range req.Cards {
+		job.Cartas = append(job.Cartas, CartaJob{Card: c})
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.jobs[id] = job
+	if err := a.salvar(job); err != nil {
+		delete(a.jobs, id)
+		return nil, err
+	}
+	return job, nil
+}
+
+func (a *armazemJobs) status(id string) (StatusJob, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job, ok := a.jobs[id]
+	if !ok {
+		return StatusJob{}, false
+	}
+	return job.status(), true
+}
+
+// Marca o job como cancelado e interrompe a execução, se houver
+func (a *armazemJobs) cancelarJob(id string) (StatusJob, bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job, ok := a.jobs[id]
+	if !ok {
+		return StatusJob{}, false, nil
+	}
+	if job.finalizado() {
+		return job.status(), true, errJobFinalizado
+	}
+	job.Status = JobCancelado
+	job.AtualizadoEm = time.Now()
+	if cancel := a.cancelar[id]; cancel != nil {
+		cancel()
+	}
+	a.salvar(job)
+	eventos.publicar(Evento{Tipo: EventoJobFinalizado, Origem: OrigemJob, JobID: id, Status: job.Status, Progresso: job.progresso()})
+	return job.status(), true, nil
+}
+
+// Dispara a execução do job em background
+func (a *armazemJobs) iniciar(ctx context.Context, id string) {
+	a.wg.Add(1)
+	go a.executar(ctx, id)
+}
+
+// Retoma os jobs que não terminaram (servidor desligado no meio)
+func (a *armazemJobs) retomarPendentes(ctx context.Context) {
+	a.mu.Lock()
+	var ids []string
+	for id, job := range a.jobs {
+		if !job.finalizado() {
+			ids = append(ids, id)
+		}
+	}
+	a.mu.Unlock()
+	for _, id := range ids {
+		fmt.Printf("[JOBS] Retomando job %s.\n", id)
+		a.iniciar(ctx, id)
+	}
+}
+
+// Aguarda os jobs em execução terminarem (após o cancelamento do contexto)
+func (a *armazemJobs) aguardar() {
+	a.wg.Wait()
+}
+
+// Busca as cartas ainda não concluídas do job, gravando cada resultado assim
+// que sai. Se o contexto cair por desligamento, o job continua "running" no
+// arquivo e é retomado no próximo start.
+func (a *armazemJobs) executar(ctx context.Context, id string) {
+	defer a.wg.Done()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	a.mu.Lock()
+	job := a.jobs[id]
+	if job == nil || job.finalizado() {
+		a.mu.Unlock()
+		return
+	}
+	req := job.Request
+	opts, err := req.opcoes()
+	var (
+		indices []int // posição de cada carta pendente em job.Cartas
+		cards   []CardInput
+	)
+	for i, c := range job.Cartas {
+		if !c.Concluida {
+			indices = append(indices, i)
+			cards = append(cards, c.Card)
+		}
+	}
+	job.Status = JobRodando
+	job.AtualizadoEm = time.Now()
+	a.cancelar[id] = cancel
+	a.salvar(job)
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		delete(a.cancelar, id)
+		a.mu.Unlock()
+	}()
+
+	var scraper Scraper
+	if err == nil {
+		scraper, err = novoScraper()
+	}
+	if err != nil {
+		a.mu.Lock()
+		job.Status, job.Erro = JobFalhou, err.Error()
+		job.AtualizadoEm = time.Now()
+		a.salvar(job)
+		a.mu.Unlock()
+		eventos.publicar(Evento{Tipo: EventoJobFinalizado, Origem: OrigemJob, JobID: id, Status: JobFalhou, Erro: err.Error()})
+		return
+	}
+	defer scraper.Fecha()
+
+	aoIniciar := func(_ int, card CardInput) {
+		eventos.publicar(Evento{Tipo: EventoCartaIniciada, Origem: OrigemJob, JobID: id, Card: &card})
+	}
+	processarCartas(ctx, scraper, cards, opts, aoIniciar, func(feitas int, r resultadoCarta) {
+		if r.Err != nil && ctx.Err() != nil {
+			return // interrompida: continua pendente
+		}
+		a.mu.Lock()
+		c := &job.Cartas[indices[r.Indice]]
+		c.Concluida, c.Resultados = true, r.Resultados
+		c.Tentativas = r.Tentativas
+		if r.Err != nil {
+			c.Erro, c.ClasseErro = r.Err.Error(), classificaErro(r.Err)
+		}
+		job.AtualizadoEm = time.Now()
+		a.salvar(job)
+		progresso := job.progresso()
+		a.mu.Unlock()
+
+		ev := eventoCarta(OrigemJob, r)
+		ev.JobID, ev.Progresso = id, progresso
+		eventos.publicar(ev)
+	})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if ctx.Err() != nil {
+		if job.Status == JobCancelado {
+			fmt.Printf("[JOBS] Job %s cancelado.\n", id)
+		}
+		return
+	}
+	job.Status = JobConcluido
+	job.AtualizadoEm = time.Now()
+	a.salvar(job)
+
+	st := job.status()
+	if len(st.Resultados) > 0 {
+		if err := a.store.SalvarResultados(st.Resultados, time.Now().Format("2006-01-02 15:04:05")); err != nil {
+			fmt.Printf("[JOBS] ERRO ao gravar resultados do job %s: %v\n", id, err)
+		}
+	}
+	fmt.Printf("[JOBS] Job %s concluído: %d cartas, %d ofertas.\n", id, st.Total, len(st.Resultados))
+	eventos.publicar(Evento{Tipo: EventoJobFinalizado, Origem: OrigemJob, JobID: id, Status: JobConcluido, Progresso: 100})
+}
+
+func novoIDJob() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// --------------------------------------------------------------------------------
+// HANDLERS /jobs
+// --------------------------------------------------------------------------------
+
+// POST /jobs - mesmo JSON do /scrape; responde 202 com o ID do job
+func jobsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Use POST para /jobs", http.StatusMethodNotAllowed)
+		return
+	}
+	var req ScrapeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("erro parse JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Cards) == 0 {
+		http.Error(w, "Nenhuma carta enviada", http.StatusBadRequest)
+		return
+	}
+	if _, err := req.opcoes(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	job, err := armazemDeJobs.criar(req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("erro ao criar job: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// O job não pode depender do request, que termina com a resposta
+	armazemDeJobs.iniciar(ctxServidor, job.ID)
+
+	st, _ := armazemDeJobs.status(job.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(st)
+}
+
+// GET /jobs/{id} - status, progresso e resultados parciais
+// DELETE /jobs/{id} - cancela o job
+func jobHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	var (
+		st  StatusJob
+		ok  bool
+		err error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		st, ok = armazemDeJobs.status(id)
+	case http.MethodDelete:
+		st, ok, err = armazemDeJobs.cancelarJob(id)
+	default:
+		http.Error(w, "Use GET ou DELETE para /jobs/{id}", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ok {
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%v (%s)", err, st.Status), http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(st)
+}
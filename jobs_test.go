@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Abre um armazém de jobs numa pasta temporária e o instala como global
+func iniciaArmazemJobs(t *testing.T) {
+	t.Helper()
+	a, err := abrirArmazemJobs(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := armazemDeJobs
+	armazemDeJobs = a
+	t.Cleanup(func() {
+		a.aguardar()
+		armazemDeJobs = original
+	})
+}
+
+func postJob(t *testing.T, req ScrapeRequest) StatusJob {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	rec := httptest.NewRecorder()
+	jobsHandler(rec, httptest.NewRequest(http.MethodPost, "/jobs", bytes.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /jobs: status %d: %s", rec.Code, rec.Body.String())
+	}
+	var st StatusJob
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func chamaJob(t *testing.T, metodo, id string) (int, StatusJob) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	jobHandler(rec, httptest.NewRequest(metodo, "/jobs/"+id, nil))
+	var st StatusJob
+	json.Unmarshal(rec.Body.Bytes(), &st)
+	return rec.Code, st
+}
+
+// Consulta o job até ele sair de pending/running
+func esperaJob(t *testing.T, id string) StatusJob {
+	t.Helper()
+	limite := time.Now().Add(10 * time.Second)
+	for time.Now().Before(limite) {
+		_, st := chamaJob(t, http.MethodGet, id)
+		if st.Status != JobPendente && st.Status != JobRodando {
+			return st
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("job %s não terminou", id)
+	return StatusJob{}
+}
+
+func TestJobsAssincronos(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	iniciaArmazemJobs(t)
+
+	cards := []CardInput{charizard, {Nome: "Inexistente", Colecao: "XXX", Numero: "0"}}
+	st := esperaJob(t, postJob(t, ScrapeRequest{Cards: cards}).ID)
+	if st.Status != JobConcluido || st.Progresso != 100 || st.Concluidas != 2 {
+		t.Fatalf("status = %+v", st)
+	}
+	if len(st.Resultados) != 1 || st.Resultados[0].Loja != "Loja Alfa" {
+		t.Errorf("resultados = %+v", st.Resultados)
+	}
+	if len(st.Erros) != 1 {
+		t.Errorf("esperava o erro da carta inexistente, veio %v", st.Erros)
+	}
+
+	// O estado sobrevive a um restart
+	reaberto, err := abrirArmazemJobs(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st2, ok := reaberto.status(st.ID); !ok || st2.Status != JobConcluido || len(st2.Resultados) != 1 {
+		t.Errorf("job recarregado = %+v (ok=%v)", st2, ok)
+	}
+
+	if code, _ := chamaJob(t, http.MethodGet, "naoexiste"); code != http.StatusNotFound {
+		t.Errorf("GET job inexistente: status %d", code)
+	}
+	if code, _ := chamaJob(t, http.MethodDelete, st.ID); code != http.StatusConflict {
+		t.Errorf("DELETE job concluído: status %d", code)
+	}
+}
+
+func TestJobCancelado(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	iniciaArmazemJobs(t)
+	config.Concorrencia = 1
+
+	fake := scraperAtrasado{atrasos: map[string]time.Duration{"1": 0, "2": time.Minute}}
+	novoOriginal := novoScraper
+	novoScraper = func() (Scraper, error) { return fake, nil }
+	t.Cleanup(func() { novoScraper = novoOriginal })
+
+	cards := []CardInput{{Nome: "A", Colecao: "X", Numero: "1"}, {Nome: "B", Colecao: "X", Numero: "2"}}
+	id := postJob(t, ScrapeRequest{Cards: cards}).ID
+
+	// Espera o resultado parcial da primeira carta antes de cancelar
+	limite := time.Now().Add(5 * time.Second)
+	for {
+		if _, st := chamaJob(t, http.MethodGet, id); st.Concluidas == 1 {
+			if st.Progresso != 50 || len(st.Resultados) != 1 {
+				t.Errorf("progresso parcial = %+v", st)
+			}
+			break
+		}
+		if time.Now().After(limite) {
+			t.Fatal("primeira carta não concluiu")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if code, st := chamaJob(t, http.MethodDelete, id); code != http.StatusOK || st.Status != JobCancelado {
+		t.Fatalf("DELETE: status %d, job %+v", code, st)
+	}
+	armazemDeJobs.aguardar()
+	if _, st := chamaJob(t, http.MethodGet, id); st.Status != JobCancelado || st.Concluidas != 1 {
+		t.Errorf("job após cancelar = %+v", st)
+	}
+}
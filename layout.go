@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// --------------------------------------------------------------------------------
+// DETECÇÃO DE MUDANÇA DE LAYOUT
+// --------------------------------------------------------------------------------
+
+// ErroLayout indica que a página não tem a estrutura esperada pelo perfil de
+// seletores (provável mudança de layout). É diferente de uma carta que
+// simplesmente não tem ofertas nas condições pedidas, que não é erro.
+type ErroLayout struct {
+	Seletores []string // campos do perfil (nomes do JSON) que não casaram
+}
+
+func (e *ErroLayout) Error() string {
+	return fmt.Sprintf("estrutura da página ausente (layout mudou?): %s", strings.Join(e.Seletores, ", "))
+}
+
+// Contadores da leitura das lojas, usados para separar "sem ofertas" de
+// "layout quebrado" depois de percorrer #marketplace-stores
+type diagnosticoLojas struct {
+	lojas       int // blocos .store encontrados
+	comCondicao int // blocos com condição reconhecida
+	aceitas     int // blocos que passaram nos filtros de condição/língua
+	comPreco    int // aceitas com preço lido
+}
+
+// Página sem o container das lojas: se nem o marcador da página de carta
+// existe, o layout mudou; com o marcador, é uma carta sem vendedores
+func erroSemContainer(temMarcador bool) error {
+	if temMarcador {
+		fmt.Println("[AVISO] Nenhum vendedor encontrado.")
+		return nil
+	}
+	return &ErroLayout{Seletores: []string{"container_lojas", "marcador_pagina"}}
+}
+
+// Retorna ErroLayout se o que foi lido não é compatível com uma página saudável
+func (d diagnosticoLojas) erro() error {
+	switch {
+	case d.lojas == 0:
+		return nil // carta sem vendedores
+	case d.comCondicao == 0:
+		return &ErroLayout{Seletores: []string{"infos_qualidade_lingua", "qualidade"}}
+	case d.aceitas > 0 && d.comPreco == 0:
+		return &ErroLayout{Seletores: []string{"preco"}}
+	}
+	return nil
+}
+
+// --------------------------------------------------------------------------------
+// GET /health/selectors - roda o perfil contra uma carta conhecida
+// --------------------------------------------------------------------------------
+
+// Resultado da checagem de um seletor do perfil
+type ChecagemSeletor struct {
+	Campo       string `json:"campo"`
+	Seletor     string `json:"seletor"`
+	Obrigatorio bool   `json:"obrigatorio"`
+	Encontrado  bool   `json:"encontrado"`
+}
+
+type SaudeSeletores struct {
+	Versao    string            `json:"versao"`
+	Carta     CardInput         `json:"carta"`
+	URL       string            `json:"url"`
+	OK        bool              `json:"ok"`
+	Quebrados []string          `json:"quebrados"`
+	Seletores []ChecagemSeletor `json:"seletores"`
+	Erro      string            `json:"erro,omitempty"`
+}
+
+// Confere cada seletor do perfil no documento. Seletores de dentro da loja
+// passam se casarem em pelo menos uma loja. Os do carrinho só aparecem depois
+// de clicar em comprar, então não são obrigatórios.
+func checaSeletores(doc *goquery.Document, p PerfilSeletores) []ChecagemSeletor {
+	lojas := doc.Find(p.ContainerLojas).Find(p.Loja)
+	infos := lojas.Find(p.InfosQualidadeLingua)
+	naPagina := func(sel string) bool { return doc.Find(sel).Length() > 0 }
+	naLoja := func(sel string) bool { return lojas.Find(sel).Length() > 0 }
+	nasInfos := func(sel string) bool { return infos.Find(sel).Length() > 0 }
+	atributoLoja := func(attr string) bool {
+		achou := false
+		lojas.EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			_, achou = s.Attr(attr)
+			return !achou
+		})
+		return achou
+	}
+
+	ch := func(campo, seletor string, obrigatorio, encontrado bool) ChecagemSeletor {
+		return ChecagemSeletor{Campo: campo, Seletor: seletor, Obrigatorio: obrigatorio, Encontrado: encontrado}
+	}
+	return []ChecagemSeletor{
+		ch("banner_cookies", p.BannerCookies, false, naPagina(p.BannerCookies)),
+		ch("botao_banner_cookies", p.BotaoBannerCookies, false, doc.Find(p.BannerCookies).Find(p.BotaoBannerCookies).Length() > 0),
+		ch("marcador_pagina", p.MarcadorPagina, true, naPagina(p.MarcadorPagina)),
+		ch("container_lojas", p.ContainerLojas, true, naPagina(p.ContainerLojas)),
+		ch("loja", p.Loja, true, lojas.Length() > 0),
+		ch("infos_qualidade_lingua", p.InfosQualidadeLingua, true, infos.Length() > 0),
+		ch("qualidade", p.Qualidade, true, nasInfos(p.Qualidade)),
+		ch("bandeira", p.Bandeira, true, nasInfos(p.Bandeira)),
+		ch("preco", p.Preco, true, naLoja(p.Preco)),
+		ch("estoque", p.Estoque, false, naLoja(p.Estoque)),
+		ch("frete", p.Frete, false, naLoja(p.Frete)),
+		ch("nome_loja", p.NomeLoja, true, naLoja(p.NomeLoja)),
+		ch("link_loja", p.LinkLoja, false, naLoja(p.LinkLoja)),
+		ch("local_loja", p.LocalLoja, false, naLoja(p.LocalLoja)),
+		ch("atributo_id_loja", p.AtributoIDLoja, false, atributoLoja(p.AtributoIDLoja)),
+		ch("botao_comprar", p.BotaoComprar, false, naLoja(p.BotaoComprar)),
+		ch("icone_carrinho", p.IconeCarrinho, false, naPagina(p.IconeCarrinho)),
+		ch("botao_ver_carrinho", p.BotaoVerCarrinho, false, naPagina(p.BotaoVerCarrinho)),
+		ch("itens_carrinho", p.ItensCarrinho, false, naPagina(p.ItensCarrinho)),
+		ch("linha_carrinho", p.LinhaCarrinho, false, doc.Find(p.ItensCarrinho).Find(p.LinhaCarrinho).Length() > 0),
+		ch("titulo_item_carrinho", p.TituloItemCarrinho, false, naPagina(p.TituloItemCarrinho)),
+		ch("estoque_item_carrinho", p.EstoqueItemCarrinho, false, naPagina(p.EstoqueItemCarrinho)),
+		ch("preco_item_carrinho", p.PrecoItemCarrinho, false, naPagina(p.PrecoItemCarrinho)),
+		ch("remover_item_carrinho", p.RemoverItemCarrinho, false, naPagina(p.RemoverItemCarrinho)),
+	}
+}
+
+// GET /health/selectors - baixa a carta de referência (config.CartaReferencia,
+// ou ?nome=&colecao=&numero=) e informa quais seletores obrigatórios quebraram.
+// Responde 503 se algum quebrou ou se a página não pôde ser baixada.
+func healthSelectorsHandler(w http.ResponseWriter, r *http.Request) {
+	carta := config.CartaReferencia
+	q := r.URL.Query()
+	if q.Get("nome") != "" {
+		carta = CardInput{Nome: q.Get("nome"), Colecao: q.Get("colecao"), Numero: q.Get("numero")}
+	}
+	p := seletores()
+	saude := SaudeSeletores{
+		Versao:    p.Versao,
+		Carta:     carta,
+		URL:       montaURLCarta(carta.Nome, carta.Colecao, carta.Numero),
+		Quebrados: []string{},
+	}
+
+	scraper := novoHTTPScraper()
+	defer scraper.Fecha()
+	doc, err := scraper.baixaPagina(r.Context(), saude.URL)
+	if err != nil {
+		saude.Erro = err.Error()
+	} else {
+		saude.Seletores = checaSeletores(doc, p)
+		for _, c := range saude.Seletores {
+			if c.Obrigatorio && !c.Encontrado {
+				saude.Quebrados = append(saude.Quebrados, c.Campo)
+			}
+		}
+		saude.OK = len(saude.Quebrados) == 0
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !saude.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(saude)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuscaDistingueSemOfertasDeLayoutQuebrado(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	scraper, err := novoScraper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scraper.Fecha()
+	opts := OpcoesBusca{Modo: ModoPrimeira}
+
+	// Pikachu só tem oferta MP: página saudável, nenhuma oferta NM
+	res, err := scraper.Busca(context.Background(), CardInput{Nome: "Pikachu", Colecao: "SVP", Numero: "27"}, opts)
+	if err != nil || len(res) != 0 {
+		t.Errorf("sem ofertas NM: resultados %+v, erro %v", res, err)
+	}
+
+	// Mewtwo é uma carta sem vendedores: a página não tem o container das lojas
+	res, err = scraper.Busca(context.Background(), CardInput{Nome: "Mewtwo", Colecao: "MEW", Numero: "150"}, opts)
+	if err != nil || len(res) != 0 {
+		t.Errorf("sem vendedores: resultados %+v, erro %v", res, err)
+	}
+
+	// Mew ex está num layout que o perfil não conhece
+	_, err = scraper.Busca(context.Background(), CardInput{Nome: "Mew ex", Colecao: "NOV", Numero: "1"}, opts)
+	var layoutErr *ErroLayout
+	if !errors.As(err, &layoutErr) {
+		t.Fatalf("esperava ErroLayout, veio %v", err)
+	}
+	if strings.Join(layoutErr.Seletores, ",") != "container_lojas,marcador_pagina" {
+		t.Errorf("seletores quebrados = %v", layoutErr.Seletores)
+	}
+}
+
+func getHealthSelectors(t *testing.T) (int, SaudeSeletores) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	healthSelectorsHandler(rec, httptest.NewRequest(http.MethodGet, "/health/selectors", nil))
+	var saude SaudeSeletores
+	if err := json.NewDecoder(rec.Body).Decode(&saude); err != nil {
+		t.Fatalf("resposta não é JSON: %v", err)
+	}
+	return rec.Code, saude
+}
+
+func TestHealthSelectors(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	config.CartaReferencia = charizard
+
+	status, saude := getHealthSelectors(t)
+	if status != http.StatusOK || !saude.OK || len(saude.Quebrados) != 0 {
+		t.Fatalf("perfil padrão: status %d, %+v", status, saude)
+	}
+
+	// Perfil com o seletor de preço errado
+	arquivo := filepath.Join(t.TempDir(), "seletores.json")
+	os.WriteFile(arquivo, []byte(`{"versao": "2", "preco": ".preco-novo"}`), 0644)
+	config.ArquivoSeletores = arquivo
+	expiraCacheSeletores(t, arquivo, time.Now())
+
+	status, saude = getHealthSelectors(t)
+	if status != http.StatusServiceUnavailable || saude.OK || saude.Versao != "2" {
+		t.Fatalf("perfil quebrado: status %d, %+v", status, saude)
+	}
+	if len(saude.Quebrados) != 1 || saude.Quebrados[0] != "preco" {
+		t.Errorf("quebrados = %v, esperado [preco]", saude.Quebrados)
+	}
+}
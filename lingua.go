@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --------------------------------------------------------------------------------
+// LÍNGUA DAS CARTAS
+// --------------------------------------------------------------------------------
+
+// Códigos normalizados de língua (ISO-like, JP no lugar de JA como na Liga)
+const (
+	LinguaPT = "PT"
+	LinguaEN = "EN"
+	LinguaJP = "JP"
+	LinguaES = "ES"
+	LinguaFR = "FR"
+	LinguaDE = "DE"
+	LinguaIT = "IT"
+	LinguaKO = "KO"
+	LinguaZH = "ZH"
+	LinguaRU = "RU"
+)
+
+// Nomes/siglas conhecidos (sem acento, em maiúsculas) para cada código
+var aliasesLinguas = map[string]string{
+	"PT": LinguaPT, "PT-BR": LinguaPT, "PORTUGUES": LinguaPT, "PORTUGUESE": LinguaPT,
+	"EN": LinguaEN, "INGLES": LinguaEN, "ENGLISH": LinguaEN,
+	"JP": LinguaJP, "JA": LinguaJP, "JAPONES": LinguaJP, "JAPANESE": LinguaJP,
+	"ES": LinguaES, "ESPANHOL": LinguaES, "SPANISH": LinguaES,
+	"FR": LinguaFR, "FRANCES": LinguaFR, "FRENCH": LinguaFR,
+	"DE": LinguaDE, "ALEMAO": LinguaDE, "GERMAN": LinguaDE,
+	"IT": LinguaIT, "ITALIANO": LinguaIT, "ITALIAN": LinguaIT,
+	"KO": LinguaKO, "KR": LinguaKO, "COREANO": LinguaKO, "KOREAN": LinguaKO,
+	"ZH": LinguaZH, "CN": LinguaZH, "CHINES": LinguaZH, "CHINESE": LinguaZH,
+	"RU": LinguaRU, "RUSSO": LinguaRU, "RUSSIAN": LinguaRU,
+}
+
+var semAcento = strings.NewReplacer(
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A",
+	"É", "E", "Ê", "E", "Í", "I",
+	"Ó", "O", "Ô", "O", "Õ", "O", "Ú", "U", "Ç", "C",
+)
+
+// Converte o título da bandeira (ex.: "Português", "Inglês") no código da língua.
+// Retorna false se o texto não for uma língua conhecida.
+func normalizaLingua(titulo string) (string, bool) {
+	chave := semAcento.Replace(strings.ToUpper(strings.TrimSpace(titulo)))
+	if cod, ok := aliasesLinguas[chave]; ok {
+		return cod, true
+	}
+	// Títulos compostos, ex.: "Idioma: Português". Só nomes por extenso,
+	// para "de", "it" etc. soltos no texto não virarem língua.
+	for _, palavra := range strings.FieldsFunc(chave, func(r rune) bool {
+		return r == ' ' || r == ':' || r == '/' || r == '(' || r == ')'
+	}) {
+		if cod, ok := aliasesLinguas[palavra]; ok && len(palavra) > 2 {
+			return cod, true
+		}
+	}
+	return "", false
+}
+
+// Valida e normaliza a lista de línguas de um request ou carta
+func normalizaLinguas(lista []string) ([]string, error) {
+	var codigos []string
+	for _, s := range lista {
+		cod, ok := normalizaLingua(s)
+		if !ok {
+			return nil, fmt.Errorf("língua inválida: %q", s)
+		}
+		codigos = append(codigos, cod)
+	}
+	return codigos, nil
+}
+
+// Escolhe a língua da loja a partir dos títulos das imagens do bloco.
+// A primeira bandeira reconhecida vence; sem nenhuma, fica o último título
+// não vazio (comportamento antigo).
+func escolheLingua(titulos []string) string {
+	bruto := ""
+	for _, t := range titulos {
+		if cod, ok := normalizaLingua(t); ok {
+			return cod
+		}
+		if t != "" {
+			bruto = t
+		}
+	}
+	return bruto
+}
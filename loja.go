@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// --------------------------------------------------------------------------------
+// IDENTIFICAÇÃO DA LOJA (vendedor)
+// --------------------------------------------------------------------------------
+
+// Siglas dos estados brasileiros, usadas para achar a UF no texto de localização
+var ufsBrasil = map[string]bool{
+	"AC": true, "AL": true, "AP": true, "AM": true, "BA": true, "CE": true, "DF": true,
+	"ES": true, "GO": true, "MA": true, "MT": true, "MS": true, "MG": true, "PA": true,
+	"PB": true, "PR": true, "PE": true, "PI": true, "RJ": true, "RN": true, "RS": true,
+	"RO": true, "RR": true, "SC": true, "SP": true, "SE": true, "TO": true,
+}
+
+// Preenche Loja, LojaID, LojaURL e Estado a partir do que foi lido no bloco .store.
+// dataID é o atributo data-id do bloco (pode vir vazio); href é o link da loja.
+func preencheLoja(r *CardResult, nome, href, dataID, local string) {
+	r.Loja = strings.TrimSpace(nome)
+	r.LojaURL = resolveURLLoja(href)
+	r.LojaID = strings.TrimSpace(dataID)
+	if r.LojaID == "" {
+		r.LojaID = idDaURLLoja(r.LojaURL)
+	}
+	r.Estado = extraiUF(local)
+}
+
+// Transforma links relativos em absolutos, usando config.Website como base
+func resolveURLLoja(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return ""
+	}
+	base, err := url.Parse(config.Website)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// Pega o identificador da loja nos parâmetros do link (?id=... ou ?store=...)
+func idDaURLLoja(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	for _, chave := range []string{"id", "store", "loja"} {
+		if v := q.Get(chave); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Extrai a UF de textos como "São Paulo - SP" ou "Curitiba/PR"
+func extraiUF(local string) string {
+	tokens := strings.FieldsFunc(local, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	// Só siglas em maiúsculas, para "de", "to", "pa"... do nome da cidade não contarem
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if t := tokens[i]; ufsBrasil[t] {
+			return t
+		}
+	}
+	return ""
+}
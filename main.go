@@ -11,13 +11,16 @@ import (
 	"io/ioutil"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	// Se preferir o chromedp, descomente:
@@ -33,29 +36,74 @@ import (
 // --------------------------------------------------------------------------------
 
 type Config struct {
-	TesseractCmd       string
-	Website            string
-	TempoEspera        time.Duration
-	Debug              bool
-	SaidaCSV           string
-	MonitorCSV         string
-	MonitorIntervalo   int
-	MonitorVariacao    int
-	OutputFolder       string
-	ChromeDriverFolder string
+	TesseractCmd        string
+	Website             string
+	TempoEspera         time.Duration
+	Debug               bool
+	SaidaCSV            string
+	MonitorCSV          string // resumo por carta, derivado do histórico
+	HistoricoCSV        string // todas as observações do monitor (append-only)
+	MonitorIntervalo    int
+	MonitorVariacao     int
+	OutputFolder        string
+	ChromeDriverFolder  string
+	Backend             string // "http" (sem navegador) ou "selenium"
+	FallbackCarrinho    bool   // no Selenium, usa o carrinho se o preço não estiver na listagem
+	TimeoutHTTP         time.Duration
+	FretePorLoja        map[string]float64 // estimativa de frete por ID ou nome da loja
+	FretePorEstado      map[string]float64 // estimativa de frete por UF da loja
+	ModoSnapshot        string             // "" (desligado), "record" ou "replay"
+	PastaSnapshots      string
+	ArquivoSeletores    string                   // perfil de seletores CSS (JSON), recarregado ao mudar
+	CartaReferencia     CardInput                // carta com ofertas usada por /health/selectors
+	MaxSessoesNavegador int                      // sessões simultâneas do Chrome no pool
+	PaginasPorSessao    int                      // recicla a sessão após N páginas (0 = nunca)
+	Concorrencia        int                      // cartas buscadas em paralelo
+	IntervaloPorHost    time.Duration            // intervalo mínimo entre requisições ao mesmo host
+	TimeoutPorCarta     time.Duration            // prazo de cada carta (0 = sem prazo)
+	ArquivoJobs         string                   // jobs.json de versões antigas (importado para o banco)
+	Armazenamento       string                   // "sqlite", "csv" ou "jsonl" (ver store.go)
+	ArquivoBanco        string                   // banco SQLite (na OutputFolder)
+	RetomarMonitor      bool                     // volta a rodar o monitor ativo após um restart
+	Retry               map[string]PoliticaRetry // política por classe de erro (sem política = sem retry)
 }
 
 var config = Config{
-	TesseractCmd:       `C:\Program Files\Tesseract-OCR\tesseract.exe`,
-	Website:            "https://www.ligapokemon.com.br/",
-	TempoEspera:        4 * time.Second,
-	Debug:              false,
-	SaidaCSV:           "resultados_final.csv",
-	MonitorCSV:         "monitor_registros.csv",
-	MonitorIntervalo:   60,
-	MonitorVariacao:    30,
-	OutputFolder:       "",
-	ChromeDriverFolder: "",
+	TesseractCmd:        `C:\Program Files\Tesseract-OCR\tesseract.exe`,
+	Website:             "https://www.ligapokemon.com.br/",
+	TempoEspera:         4 * time.Second,
+	Debug:               false,
+	SaidaCSV:            "resultados_final.csv",
+	MonitorCSV:          "monitor_registros.csv",
+	HistoricoCSV:        "monitor_historico.csv",
+	MonitorIntervalo:    60,
+	MonitorVariacao:     30,
+	OutputFolder:        "",
+	ChromeDriverFolder:  "",
+	Backend:             "http",
+	FallbackCarrinho:    false,
+	TimeoutHTTP:         30 * time.Second,
+	FretePorLoja:        map[string]float64{},
+	FretePorEstado:      map[string]float64{},
+	ModoSnapshot:        SnapshotDesligado,
+	PastaSnapshots:      "snapshots",
+	ArquivoSeletores:    "seletores.json",
+	CartaReferencia:     CardInput{Nome: "Charizard ex", Colecao: "OBF", Numero: "125"},
+	MaxSessoesNavegador: 2,
+	PaginasPorSessao:    50,
+	Concorrencia:        4,
+	IntervaloPorHost:    500 * time.Millisecond,
+	TimeoutPorCarta:     2 * time.Minute,
+	ArquivoJobs:         "jobs.json",
+	Armazenamento:       ArmazenamentoSQLite,
+	ArquivoBanco:        "precos.db",
+	RetomarMonitor:      false,
+	Retry: map[string]PoliticaRetry{
+		ClasseRede:    {Tentativas: 3, Base: time.Second, Max: 10 * time.Second},
+		ClasseTimeout: {Tentativas: 2, Base: 2 * time.Second, Max: 10 * time.Second},
+		ClasseSeletor: {Tentativas: 2, Base: 2 * time.Second, Max: 5 * time.Second},
+		ClasseDriver:  {Tentativas: 3, Base: 2 * time.Second, Max: 30 * time.Second},
+	},
 }
 
 // --------------------------------------------------------------------------------
@@ -64,21 +112,29 @@ var config = Config{
 
 // Estrutura para representar uma carta no CSV de entrada
 type CardInput struct {
-	Nome    string `json:"nome"`
-	Colecao string `json:"colecao"`
-	Numero  string `json:"numero"`
+	Nome       string   `json:"nome"`
+	Colecao    string   `json:"colecao"`
+	Numero     string   `json:"numero"`
+	Linguas    []string `json:"linguas,omitempty"`    // ex.: ["PT"]; sobrepõe as línguas do request
+	Quantidade int      `json:"quantidade,omitempty"` // cópias desejadas (padrão 1)
 }
 
 // Estrutura para representar resultados do scraping
 type CardResult struct {
-	Nome       string  `json:"nome"`
-	Colecao    string  `json:"colecao"`
-	Numero     string  `json:"numero"`
-	Condicao   string  `json:"condicao"`
-	Quantidade int     `json:"quantidade"`
-	Preco      float64 `json:"preco"`
-	PrecoTotal float64 `json:"preco_total"`
-	Lingua     string  `json:"lingua"`
+	Nome             string   `json:"nome"`
+	Colecao          string   `json:"colecao"`
+	Numero           string   `json:"numero"`
+	Condicao         Condicao `json:"condicao"`
+	Quantidade       int      `json:"quantidade"` // estoque da loja
+	Preco            float64  `json:"preco"`
+	PrecoTotal       float64  `json:"preco_total"` // preço × quantidade pedida + frete
+	Frete            float64  `json:"frete"`
+	QuantidadePedida int      `json:"quantidade_pedida"`
+	Lingua           string   `json:"lingua"`
+	Loja             string   `json:"loja"`
+	LojaID           string   `json:"loja_id"`
+	LojaURL          string   `json:"loja_url"`
+	Estado           string   `json:"estado"`
 }
 
 // Estrutura para monitoramento
@@ -90,6 +146,9 @@ type MonitorEntry struct {
 	DataAtual    string  `json:"data_atual"`
 	PrecoInicial float64 `json:"preco_inicial"`
 	DataInicial  string  `json:"data_inicial"`
+	Loja         string  `json:"loja"`
+	LojaID       string  `json:"loja_id"`
+	Estado       string  `json:"estado"`
 }
 
 // --------------------------------------------------------------------------------
@@ -99,8 +158,13 @@ type MonitorEntry struct {
 var (
 	monitorRunning bool
 	monitorPaused  bool
+	monitorCancel  context.CancelFunc // aborta a checagem em andamento
+	monitorEstado  EstadoMonitor      // monitor em execução, como gravado no store
 	monitorMutex   sync.Mutex
 	wgMonitor      sync.WaitGroup
+
+	// Contexto do servidor: cancelado no desligamento, aborta o scraping em andamento
+	ctxServidor, cancelServidor = context.WithCancel(context.Background())
 )
 
 // --------------------------------------------------------------------------------
@@ -180,61 +244,48 @@ func carregarListaCardsCSV(caminhoCSV string) ([]CardInput, error) {
 	return lista, nil
 }
 
-// Salva resultados em CSV (append ou cria novo)
-func salvarResultadosCSV(resultados []CardResult, caminhoSaida string) error {
-	if len(resultados) == 0 {
-		fmt.Println("[AVISO] Nenhum resultado para salvar.")
-		return nil
-	}
-
-	colunas := []string{
-		"nome", "colecao", "numero",
-		"condicao", "quantidade", "preco",
-		"preco_total", "lingua",
-	}
-
-	existe := false
-	if _, err := os.Stat(caminhoSaida); err == nil {
-		existe = true
-	}
-	var file *os.File
-	var err error
-	if existe {
-		file, err = os.OpenFile(caminhoSaida, os.O_APPEND|os.O_WRONLY, 0644)
-	} else {
-		file, err = os.Create(caminhoSaida)
-	}
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+// Colunas do CSV de resultados
+var colunasResultadosCSV = []string{
+	"nome", "colecao", "numero",
+	"condicao", "quantidade", "preco",
+	"preco_total", "lingua", "loja",
+	"loja_id", "loja_url", "estado",
+	"frete", "quantidade_pedida",
+}
 
-	writer := csv.NewWriter(file)
+// Escreve os resultados em CSV (separador ';'), com ou sem cabeçalho
+func escreverResultadosCSV(w io.Writer, resultados []CardResult, cabecalho bool) error {
+	writer := csv.NewWriter(w)
 	writer.Comma = ';'
 
-	if !existe {
-		writer.Write(colunas)
+	if cabecalho {
+		if err := writer.Write(colunasResultadosCSV); err != nil {
+			return err
+		}
 	}
 	for _, r := range resultados {
 		record := []string{
 			r.Nome,
 			r.Colecao,
 			r.Numero,
-			r.Condicao,
+			string(r.Condicao),
 			strconv.Itoa(r.Quantidade),
 			fmt.Sprintf("%.2f", r.Preco),
 			fmt.Sprintf("%.2f", r.PrecoTotal),
 			r.Lingua,
+			r.Loja,
+			r.LojaID,
+			r.LojaURL,
+			r.Estado,
+			fmt.Sprintf("%.2f", r.Frete),
+			strconv.Itoa(r.QuantidadePedida),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
 		}
-		writer.Write(record)
 	}
 	writer.Flush()
-	return nil
-}
-
-// Limpar CSV
-func limparCSV(caminho string) {
-	os.Remove(caminho)
+	return writer.Error()
 }
 
 // --------------------------------------------------------------------------------
@@ -403,10 +454,12 @@ func checkAndDownloadChromeDriver() (string, error) {
 // FUNÇÕES DE SCRAPING (exemplo com Selenium + ChromeDriver)
 // --------------------------------------------------------------------------------
 
-// Abre o ChromeDriver usando o Selenium
-func iniciarSelenium(driverPath string) (selenium.WebDriver, func(), error) {
+// Abre o ChromeDriver na porta informada usando o Selenium
+func iniciarSelenium(ctx context.Context, driverPath string, port int) (selenium.WebDriver, func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	// Setup das capacidades
-	const port = 9515
 	opts := []selenium.ServiceOption{}
 	selenium.SetDebug(config.Debug)
 	service, err := selenium.NewChromeDriverService(driverPath, port, opts...)
@@ -436,100 +489,234 @@ func iniciarSelenium(driverPath string) (selenium.WebDriver, func(), error) {
 		cleanup()
 		return nil, nil, fmt.Errorf("erro ao criar sessão selenium: %v", err)
 	}
+	// A criação da sessão não é cancelável; se o contexto caiu nesse meio tempo, desfaz
+	if err := ctx.Err(); err != nil {
+		wd.Quit()
+		cleanup()
+		return nil, nil, err
+	}
 	return wd, cleanup, nil
 }
 
 // Função simplificada de scrape, baseada nas suas funções Python
-func buscaCartaCompleta(wd selenium.WebDriver, nome, colecao, numero string) ([]CardResult, error) {
+func buscaCartaCompleta(ctx context.Context, wd selenium.WebDriver, card CardInput, opts OpcoesBusca) ([]CardResult, error) {
 	resultados := []CardResult{}
+	p := seletores()
+	nome, colecao, numero := card.Nome, card.Colecao, card.Numero
 
-	// Monta URL
-	nomeUrl := strings.ReplaceAll(nome, " ", "%20")
-	url := fmt.Sprintf("%s?view=cards/card&card=%s%%20(%s)&ed=%s&num=%s",
-		config.Website, nomeUrl, numero, colecao, numero)
+	url := montaURLCarta(nome, colecao, numero)
+	if err := limitadorPolidez.esperarVez(ctx, url); err != nil {
+		return resultados, err
+	}
 	err := wd.Get(url)
 	if err != nil {
 		return resultados, err
 	}
-	time.Sleep(config.TempoEspera)
+	if err := esperar(ctx, config.TempoEspera); err != nil {
+		return resultados, err
+	}
+
+	if config.ModoSnapshot == SnapshotGravar {
+		if html, err := wd.PageSource(); err == nil {
+			if err := gravaSnapshot(chaveSnapshot(colecao, numero), []byte(html)); err != nil {
+				fmt.Printf("[SNAPSHOT] ERRO ao gravar: %v\n", err)
+			}
+		}
+	}
 
 	// Fecha banner cookies (tentativa)
-	fechaBannerCookies(wd)
+	fechaBannerCookies(ctx, wd, p)
 
-	// Tenta localizar #marketplace-stores
-	storesContainer, err := wd.FindElement(selenium.ByCSSSelector, "#marketplace-stores")
+	// Tenta localizar o container das lojas (#marketplace-stores)
+	storesContainer, err := wd.FindElement(selenium.ByCSSSelector, p.ContainerLojas)
 	if err != nil {
-		fmt.Println("[AVISO] Sem marketplace-stores. Nenhum vendedor encontrado.")
-		return resultados, nil
+		_, errMarcador := wd.FindElement(selenium.ByCSSSelector, p.MarcadorPagina)
+		return resultados, erroSemContainer(errMarcador == nil)
 	}
-	stores, err := storesContainer.FindElements(selenium.ByCSSSelector, ".store")
-	if err != nil {
+	stores, err := storesContainer.FindElements(selenium.ByCSSSelector, p.Loja)
+	if err != nil || len(stores) == 0 {
+		fmt.Println("[AVISO] Nenhum vendedor encontrado.")
 		return resultados, nil
 	}
 
-	for _, store := range stores {
-		lingua, cond := extraiLinguaECondicao(store)
-		if strings.Contains(strings.ToUpper(cond), "NM") {
-			btnComprar, err := localizaBotaoComprarNM(store)
-			if err == nil && btnComprar != nil {
-				// Clica comprar
-				fechaBannerCookies(wd)
-				btnComprar.Click()
-				time.Sleep(1 * time.Second)
-				abreModalCarrinho(wd)
-				rowCarrinho, err2 := localizaItemCarrinho(wd, nome, numero)
-				if err2 == nil && rowCarrinho != nil {
-					dados, _ := extraiDadosItemCarrinho(rowCarrinho)
-					dados.Nome = nome
-					dados.Colecao = colecao
-					dados.Numero = numero
-					dados.Lingua = lingua
-					dados.Condicao = cond
-					resultados = append(resultados, dados)
-
-					removeItemCarrinho(rowCarrinho)
-					break // se já achou 1, pode sair
-				}
+	var diag diagnosticoLojas
+	for i := 0; i < len(stores); i++ {
+		store := stores[i]
+		if err := ctx.Err(); err != nil {
+			return resultados, err
+		}
+		diag.lojas++
+		lingua, cond := extraiLinguaECondicao(store, p)
+		if cond != "" {
+			diag.comCondicao++
+		}
+		if !opts.aceitaCondicao(cond) || !opts.aceitaLingua(card, lingua) {
+			continue
+		}
+		diag.aceitas++
+		// Loja e frete são lidos antes do carrinho, que sai da página
+		var loja CardResult
+		extraiIdentidadeLoja(store, &loja, p)
+		frete := textoElemento(store, p.Frete)
+
+		// Lê preço e estoque direto da listagem da loja
+		dados, ok := extraiPrecoEstoqueLoja(store, p)
+		if !ok && config.FallbackCarrinho {
+			dados, ok = precoViaCarrinho(ctx, wd, store, nome, numero, p)
+			// Os elementos da página antiga não valem mais: recarrega a carta
+			stores, err = recarregaLojas(ctx, wd, url, p)
+			if err != nil {
+				fmt.Printf("[AVISO] Não foi possível voltar à página da carta: %v\n", err)
+				stores = nil // encerra o loop com o que já foi lido
 			}
 		}
+		if !ok {
+			continue
+		}
+		diag.comPreco++
+		dados.Nome = nome
+		dados.Colecao = colecao
+		dados.Numero = numero
+		dados.Lingua = lingua
+		dados.Condicao = cond
+		dados.Loja, dados.LojaID, dados.LojaURL, dados.Estado = loja.Loja, loja.LojaID, loja.LojaURL, loja.Estado
+		aplicaPrecoTotal(&dados, card, frete)
+		resultados = append(resultados, dados)
+		if opts.Modo == ModoPrimeira {
+			break // se já achou 1, pode sair
+		}
+	}
+
+	if err := diag.erro(); err != nil {
+		return resultados, err
+	}
+	return selecionaOfertas(resultados, opts.Modo), nil
+}
+
+// Volta à página da carta (depois do fluxo do carrinho) e busca as lojas de novo
+func recarregaLojas(ctx context.Context, wd selenium.WebDriver, url string, p PerfilSeletores) ([]selenium.WebElement, error) {
+	if err := limitadorPolidez.esperarVez(ctx, url); err != nil {
+		return nil, err
+	}
+	if err := wd.Get(url); err != nil {
+		return nil, err
+	}
+	if err := esperar(ctx, config.TempoEspera); err != nil {
+		return nil, err
+	}
+	fechaBannerCookies(ctx, wd, p)
+	container, err := wd.FindElement(selenium.ByCSSSelector, p.ContainerLojas)
+	if err != nil {
+		return nil, err
+	}
+	return container.FindElements(selenium.ByCSSSelector, p.Loja)
+}
+
+// Monta a URL da página da carta no site da Liga
+func montaURLCarta(nome, colecao, numero string) string {
+	nomeUrl := strings.ReplaceAll(nome, " ", "%20")
+	return fmt.Sprintf("%s?view=cards/card&card=%s%%20(%s)&ed=%s&num=%s",
+		config.Website, nomeUrl, numero, colecao, numero)
+}
+
+// Lê preço e estoque exibidos no bloco da loja, sem mexer no carrinho
+func extraiPrecoEstoqueLoja(store selenium.WebElement, p PerfilSeletores) (CardResult, bool) {
+	dados := CardResult{}
+	precoElem, err := store.FindElement(selenium.ByCSSSelector, p.Preco)
+	if err != nil {
+		return dados, false
+	}
+	txt, _ := precoElem.Text()
+	dados.Preco = convertePrecoParaFloat(txt)
+	dados.PrecoTotal = dados.Preco
+	if dados.Preco == 0 {
+		return dados, false
+	}
+	estoqueElem, err := store.FindElement(selenium.ByCSSSelector, p.Estoque)
+	if err == nil {
+		txt, _ := estoqueElem.Text()
+		dados.Quantidade = parseEstoque(txt)
+	}
+	return dados, true
+}
+
+// Texto do primeiro elemento que casar com o seletor (vazio se não existir)
+func textoElemento(elem selenium.WebElement, seletor string) string {
+	e, err := elem.FindElement(selenium.ByCSSSelector, seletor)
+	if err != nil {
+		return ""
 	}
+	txt, _ := e.Text()
+	return txt
+}
+
+// Nome, link e localização da loja exibidos no bloco do vendedor
+func extraiIdentidadeLoja(store selenium.WebElement, dados *CardResult, p PerfilSeletores) {
+	var href string
+	if link, err := store.FindElement(selenium.ByCSSSelector, p.LinkLoja); err == nil {
+		href, _ = link.GetAttribute("href")
+	}
+	nome := textoElemento(store, p.NomeLoja)
+	local := textoElemento(store, p.LocalLoja)
+	dataID, _ := store.GetAttribute(p.AtributoIDLoja)
+	preencheLoja(dados, nome, href, dataID, local)
+}
 
-	return resultados, nil
+// Modo fallback: adiciona ao carrinho, lê o preço na linha do carrinho e remove o item
+func precoViaCarrinho(ctx context.Context, wd selenium.WebDriver, store selenium.WebElement, nome, numero string, p PerfilSeletores) (CardResult, bool) {
+	btnComprar, err := localizaBotaoComprar(store, p)
+	if err != nil || btnComprar == nil {
+		return CardResult{}, false
+	}
+	// Clica comprar
+	fechaBannerCookies(ctx, wd, p)
+	btnComprar.Click()
+	if esperar(ctx, 1*time.Second) != nil {
+		return CardResult{}, false
+	}
+	abreModalCarrinho(ctx, wd, p)
+	rowCarrinho, err := localizaItemCarrinho(wd, nome, numero, p)
+	if err != nil || rowCarrinho == nil {
+		return CardResult{}, false
+	}
+	dados, _ := extraiDadosItemCarrinho(rowCarrinho, p)
+	removeItemCarrinho(ctx, rowCarrinho, p)
+	return dados, true
 }
 
 // Fecha banner cookies
-func fechaBannerCookies(wd selenium.WebDriver) {
-	banner, err := wd.FindElement(selenium.ByCSSSelector, "#lgpd-cookie")
+func fechaBannerCookies(ctx context.Context, wd selenium.WebDriver, p PerfilSeletores) {
+	banner, err := wd.FindElement(selenium.ByCSSSelector, p.BannerCookies)
 	if err == nil {
-		btn, err2 := banner.FindElement(selenium.ByTagName, "button")
+		btn, err2 := banner.FindElement(selenium.ByCSSSelector, p.BotaoBannerCookies)
 		if err2 == nil {
 			btn.Click()
-			time.Sleep(time.Second)
+			esperar(ctx, time.Second)
 			fmt.Println("[INFO] Banner de cookies fechado.")
 		}
 	}
 }
 
 // Extrai língua e condição
-func extraiLinguaECondicao(store selenium.WebElement) (string, string) {
+func extraiLinguaECondicao(store selenium.WebElement, p PerfilSeletores) (string, Condicao) {
 	lingua := ""
-	condicao := ""
-	infos, err := store.FindElement(selenium.ByCSSSelector, ".infos-quality-and-language.desktop-only")
+	var condicao Condicao
+	infos, err := store.FindElement(selenium.ByCSSSelector, p.InfosQualidadeLingua)
 	if err != nil {
 		return lingua, condicao
 	}
-	imgs, _ := infos.FindElements(selenium.ByTagName, "img")
+	imgs, _ := infos.FindElements(selenium.ByCSSSelector, p.Bandeira)
+	var titulos []string
 	for _, img := range imgs {
 		title, _ := img.GetAttribute("title")
-		if title != "" {
-			lingua = title
-		}
+		titulos = append(titulos, title)
 	}
-	qs, _ := infos.FindElements(selenium.ByCSSSelector, ".quality")
+	lingua = escolheLingua(titulos)
+	qs, _ := infos.FindElements(selenium.ByCSSSelector, p.Qualidade)
 	for _, q := range qs {
 		title, _ := q.GetAttribute("title")
-		if strings.Contains(strings.ToUpper(title), "NM") {
-			condicao = title
+		if c, ok := parseCondicao(title); ok {
+			condicao = c
 			break
 		}
 	}
@@ -537,8 +724,8 @@ func extraiLinguaECondicao(store selenium.WebElement) (string, string) {
 }
 
 // Localiza botão comprar
-func localizaBotaoComprarNM(store selenium.WebElement) (selenium.WebElement, error) {
-	btn, err := store.FindElement(selenium.ByCSSSelector, "div.btn-green.cursor-pointer")
+func localizaBotaoComprar(store selenium.WebElement, p PerfilSeletores) (selenium.WebElement, error) {
+	btn, err := store.FindElement(selenium.ByCSSSelector, p.BotaoComprar)
 	if err != nil {
 		return nil, err
 	}
@@ -546,31 +733,33 @@ func localizaBotaoComprarNM(store selenium.WebElement) (selenium.WebElement, err
 }
 
 // Abre modal carrinho
-func abreModalCarrinho(wd selenium.WebDriver) {
-	iconeCarrinho, err := wd.FindElement(selenium.ByCSSSelector, "div.cart-icon-container.icon-container")
+func abreModalCarrinho(ctx context.Context, wd selenium.WebDriver, p PerfilSeletores) {
+	iconeCarrinho, err := wd.FindElement(selenium.ByCSSSelector, p.IconeCarrinho)
 	if err == nil {
 		iconeCarrinho.Click()
-		time.Sleep(time.Second)
-		meuCarrinhoBtn, err2 := wd.FindElement(selenium.ByCSSSelector, "a.btn-view-cart")
+		if esperar(ctx, time.Second) != nil {
+			return
+		}
+		meuCarrinhoBtn, err2 := wd.FindElement(selenium.ByCSSSelector, p.BotaoVerCarrinho)
 		if err2 == nil {
 			meuCarrinhoBtn.Click()
-			time.Sleep(config.TempoEspera)
+			esperar(ctx, config.TempoEspera)
 		}
 	}
 }
 
 // Localiza item no carrinho
-func localizaItemCarrinho(wd selenium.WebDriver, nome, numero string) (selenium.WebElement, error) {
-	itens, err := wd.FindElement(selenium.ByCSSSelector, "div.itens")
+func localizaItemCarrinho(wd selenium.WebDriver, nome, numero string, p PerfilSeletores) (selenium.WebElement, error) {
+	itens, err := wd.FindElement(selenium.ByCSSSelector, p.ItensCarrinho)
 	if err != nil {
 		return nil, err
 	}
-	rows, err := itens.FindElements(selenium.ByCSSSelector, "div.row")
+	rows, err := itens.FindElements(selenium.ByCSSSelector, p.LinhaCarrinho)
 	if err != nil {
 		return nil, err
 	}
 	for _, row := range rows {
-		tituloElem, err := row.FindElement(selenium.ByCSSSelector, "p.cardtitle a")
+		tituloElem, err := row.FindElement(selenium.ByCSSSelector, p.TituloItemCarrinho)
 		if err != nil {
 			continue
 		}
@@ -583,14 +772,14 @@ func localizaItemCarrinho(wd selenium.WebDriver, nome, numero string) (selenium.
 }
 
 // Extrai dados do item no carrinho
-func extraiDadosItemCarrinho(row selenium.WebElement) (CardResult, error) {
+func extraiDadosItemCarrinho(row selenium.WebElement, p PerfilSeletores) (CardResult, error) {
 	dados := CardResult{}
-	estoqueElem, err := row.FindElement(selenium.ByCSSSelector, "div.item-estoque")
+	estoqueElem, err := row.FindElement(selenium.ByCSSSelector, p.EstoqueItemCarrinho)
 	if err == nil {
 		txt, _ := estoqueElem.Text()
 		dados.Quantidade = parseEstoque(txt)
 	}
-	precoElem, err2 := row.FindElement(selenium.ByCSSSelector, "div.preco-total.item-total")
+	precoElem, err2 := row.FindElement(selenium.ByCSSSelector, p.PrecoItemCarrinho)
 	if err2 == nil {
 		txt, _ := precoElem.Text()
 		val := convertePrecoParaFloat(txt)
@@ -601,11 +790,23 @@ func extraiDadosItemCarrinho(row selenium.WebElement) (CardResult, error) {
 }
 
 // Remove item do carrinho
-func removeItemCarrinho(row selenium.WebElement) {
-	btnRemove, err := row.FindElement(selenium.ByCSSSelector, "div.btn-circle.remove.delete.item-delete")
+func removeItemCarrinho(ctx context.Context, row selenium.WebElement, p PerfilSeletores) {
+	btnRemove, err := row.FindElement(selenium.ByCSSSelector, p.RemoverItemCarrinho)
 	if err == nil {
 		btnRemove.Click()
-		time.Sleep(1 * time.Second)
+		esperar(ctx, 1*time.Second)
+	}
+}
+
+// Dorme por d ou até o contexto ser cancelado (nesse caso retorna ctx.Err())
+func esperar(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
 	}
 }
 
@@ -632,20 +833,25 @@ func convertePrecoParaFloat(txt string) float64 {
 // FUNÇÕES DE MONITORAMENTO EM BACKGROUND
 // --------------------------------------------------------------------------------
 
-// Executa monitoramento em loop
-func monitorLoop(lista []CardInput) {
+// Executa monitoramento em loop até monitorRunning ficar false ou o contexto
+// ser cancelado (stop ou desligamento do servidor)
+func monitorLoop(ctx context.Context, lista []CardInput, opts OpcoesBusca) {
 	defer wgMonitor.Done()
+	defer fmt.Println("[MONITOR] finalizado.")
 	checkCount := 0
+	inicioMonitor := time.Now().Format("20060102-150405")
+	falhasScraper := 0 // falhas seguidas ao abrir o backend
 	for {
 		monitorMutex.Lock()
-		if !monitorRunning {
+		if !monitorRunning || ctx.Err() != nil {
 			monitorMutex.Unlock()
-			fmt.Println("[MONITOR] finalizado.")
 			return
 		}
 		if monitorPaused {
 			monitorMutex.Unlock()
-			time.Sleep(1 * time.Second)
+			if esperar(ctx, 1*time.Second) != nil {
+				return
+			}
 			continue
 		}
 		monitorMutex.Unlock()
@@ -653,42 +859,68 @@ func monitorLoop(lista []CardInput) {
 		checkCount++
 		fmt.Printf("[MONITOR] Checagem #%d para %d cartas.\n", checkCount, len(lista))
 
-		// Abre Selenium
-		driverPath, err := checkAndDownloadChromeDriver()
-		if err != nil {
-			fmt.Printf("[MONITOR] ERRO download chromedriver: %v\n", err)
-			time.Sleep(10 * time.Second)
-			continue
-		}
-		wd, cleanup, err := iniciarSelenium(driverPath)
+		// Abre o backend de scraping
+		scraper, err := novoScraper()
 		if err != nil {
-			fmt.Printf("[MONITOR] ERRO iniciar selenium: %v\n", err)
-			time.Sleep(10 * time.Second)
+			falhasScraper++
+			espera := 10 * time.Second
+			if pol, ok := config.Retry[ClasseDriver]; ok {
+				espera = pol.espera(falhasScraper)
+			}
+			fmt.Printf("[MONITOR] ERRO iniciar scraper (%dª falha, nova tentativa em %v): %v\n", falhasScraper, espera.Round(time.Second), err)
+			if esperar(ctx, espera) != nil {
+				return
+			}
 			continue
 		}
+		falhasScraper = 0
 
 		var resultsMonitor []CardResult
 
-		for i, card := range lista {
-			percent := int((float64(i+1) / float64(len(lista))) * 100)
+		// Cartas em paralelo; o callback roda uma carta por vez, na ordem de conclusão
+		checagem := checkCount
+		idChecagem := fmt.Sprintf("%s-%d", inicioMonitor, checkCount) // identifica a checagem no histórico
+		aoIniciar := func(_ int, card CardInput) {
+			eventos.publicar(Evento{Tipo: EventoCartaIniciada, Origem: OrigemMonitor, Checagem: checagem, Card: &card})
+		}
+		processarCartas(ctx, scraper, lista, opts, aoIniciar, func(feitas int, r resultadoCarta) {
+			card, ret, err2 := r.Card, r.Resultados, r.Err
+			percent := int((float64(feitas) / float64(len(lista))) * 100)
 			fmt.Printf("[MONITOR] %s (%s - %s): %d%%\n", card.Nome, card.Colecao, card.Numero, percent)
+			if ctx.Err() == nil {
+				ev := eventoCarta(OrigemMonitor, r)
+				ev.Checagem, ev.Progresso = checagem, percent
+				eventos.publicar(ev)
+			}
 
-			ret, err2 := buscaCartaCompleta(wd, card.Nome, card.Colecao, card.Numero)
+			var layoutErr *ErroLayout
 			if err2 == nil && len(ret) > 0 {
 				resultsMonitor = append(resultsMonitor, ret...)
-				precoAtual := ret[0].Preco
 				dtStr := time.Now().Format("2006-01-02 15:04:05")
-				_ = salvarMonitoramento(card.Nome, card.Colecao, card.Numero, precoAtual, dtStr, filepath.Join(config.OutputFolder, config.MonitorCSV))
-				fmt.Printf("[MONITOR] %s preco %.2f\n", card.Nome, precoAtual)
+				if err := salvarMonitoramento(ret, idChecagem, dtStr); err != nil {
+					fmt.Printf("[MONITOR] ERRO ao gravar histórico p/ %s: %v\n", card.Nome, err)
+				}
+				sel := ret[indiceMaisBarata(ret)]
+				fmt.Printf("[MONITOR] %s preco %.2f (%s)\n", card.Nome, sel.Preco, sel.Loja)
+			} else if ctx.Err() != nil {
+				fmt.Printf("[MONITOR] %s interrompida.\n", card.Nome)
+			} else if errors.As(err2, &layoutErr) {
+				fmt.Printf("[MONITOR] ERRO layout p/ %s: %v\n", card.Nome, layoutErr)
+			} else if err2 != nil {
+				fmt.Printf("[MONITOR] ERRO %s p/ %s após %d tentativa(s): %v\n", classificaErro(err2), card.Nome, r.Tentativas, err2)
 			} else {
-				fmt.Printf("[MONITOR] NM não encontrado p/ %s\n", card.Nome)
+				fmt.Printf("[MONITOR] Nenhuma oferta nas condições pedidas p/ %s\n", card.Nome)
 			}
+		})
+		scraper.Fecha()
+		if ctx.Err() == nil {
+			eventos.publicar(Evento{Tipo: EventoChecagemConcluida, Origem: OrigemMonitor, Checagem: checagem, Resultados: resultsMonitor, Progresso: 100})
 		}
-		wd.Quit()
-		cleanup()
 
 		if len(resultsMonitor) > 0 {
-			_ = salvarResultadosCSV(resultsMonitor, filepath.Join(config.OutputFolder, config.SaidaCSV))
+			if err := store.SalvarResultados(resultsMonitor, time.Now().Format("2006-01-02 15:04:05")); err != nil {
+				fmt.Printf("[MONITOR] ERRO ao gravar resultados: %v\n", err)
+			}
 		}
 
 		// Calcula tempo de espera
@@ -703,76 +935,50 @@ func monitorLoop(lista []CardInput) {
 				return
 			}
 			if monitorPaused {
-				monitorMutex.Unlock()
-				time.Sleep(1 * time.Second)
 				s--
-				continue
 			}
 			monitorMutex.Unlock()
-			time.Sleep(1 * time.Second)
+			if esperar(ctx, 1*time.Second) != nil {
+				return
+			}
 		}
 	}
 }
 
-// Salva no CSV de monitoramento (semelhante ao seu Python)
-func salvarMonitoramento(nome, colecao, numero string, preco float64, dataStr, caminho string) error {
-	colunas := []string{
-		"nome", "colecao", "numero", "preco_atual",
-		"data_atual", "preco_inicial", "data_inicial",
-	}
-	existe := false
-	if _, err := os.Stat(caminho); err == nil {
-		existe = true
+// Colunas do CSV de monitoramento
+var colunasMonitorCSV = []string{
+	"nome", "colecao", "numero", "preco_atual",
+	"data_atual", "preco_inicial", "data_inicial",
+	"loja", "loja_id", "estado",
+}
+
+// Registra as ofertas de uma carta numa checagem do monitor como observações
+// novas no histórico (o resumo por carta é derivado dele).
+// A oferta de menor preço total define o preço da carta (no modo "all" as
+// ofertas vêm na ordem da página, que não é a do preço).
+func salvarMonitoramento(ofertas []CardResult, checagem, dataStr string) error {
+	if len(ofertas) == 0 {
+		return nil
 	}
-	// Se existe, carregamos p/ ver se já tinha registro
-	var dfExistente []MonitorEntry
-	if existe {
-		dfExistente, _ = carregarMonitorCSV(caminho)
+	selecionada := indiceMaisBarata(ofertas)
+	r := ofertas[selecionada]
+	precoAnterior, tinha, err := store.UltimoPreco(r.Nome, r.Colecao, r.Numero)
+	if err != nil {
+		return err
 	}
 
-	precoFloat := preco
-	idx := -1
-	for i, me := range dfExistente {
-		if me.Nome == nome && me.Colecao == colecao && me.Numero == numero {
-			idx = i
-			break
-		}
+	var novas []Observacao
+	for i, o := range ofertas {
+		novas = append(novas, novaObservacao(o, checagem, dataStr, i == selecionada))
 	}
-	if idx != -1 {
-		// atualiza
-		if dfExistente[idx].PrecoInicial == 0 {
-			dfExistente[idx].PrecoInicial = precoFloat
-			dfExistente[idx].DataInicial = dataStr
-		}
-		dfExistente[idx].PrecoAtual = precoFloat
-		dfExistente[idx].DataAtual = dataStr
-		// Sobrescreve CSV
-		return sobrescreverMonitorCSV(caminho, dfExistente, colunas)
-	} else {
-		// adiciona
-		f, err := os.OpenFile(caminho, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-
-		writer := csv.NewWriter(f)
-		writer.Comma = ';'
-		if !existe {
-			writer.Write(colunas)
-		}
-		record := []string{
-			nome,
-			colecao,
-			numero,
-			fmt.Sprintf("%.2f", precoFloat),
-			dataStr,
-			fmt.Sprintf("%.2f", precoFloat),
-			dataStr,
-		}
-		writer.Write(record)
-		writer.Flush()
-		fmt.Printf("[INFO] Monitoramento salvo p/ %s (%.2f)\n", nome, precoFloat)
+	if err := store.AnexarObservacoes(novas); err != nil {
+		return err
+	}
+	if !tinha {
+		fmt.Printf("[INFO] Monitoramento salvo p/ %s (%.2f)\n", r.Nome, r.Preco)
+	}
+	if tinha && precoAnterior != r.Preco {
+		notificarMudancaPreco(r, precoAnterior, dataStr)
 	}
 	return nil
 }
@@ -786,6 +992,7 @@ func carregarMonitorCSV(caminho string) ([]MonitorEntry, error) {
 	defer f.Close()
 	reader := csv.NewReader(f)
 	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
 	cols, err := reader.Read()
 	if err != nil {
 		return lista, err
@@ -810,20 +1017,28 @@ func carregarMonitorCSV(caminho string) ([]MonitorEntry, error) {
 		me.DataAtual = line[colIndex["data_atual"]]
 		me.PrecoInicial, _ = strconv.ParseFloat(line[colIndex["preco_inicial"]], 64)
 		me.DataInicial = line[colIndex["data_inicial"]]
+		// Colunas de loja não existem em arquivos antigos
+		if i, ok := colIndex["loja"]; ok {
+			me.Loja = line[i]
+		}
+		if i, ok := colIndex["loja_id"]; ok {
+			me.LojaID = line[i]
+		}
+		if i, ok := colIndex["estado"]; ok {
+			me.Estado = line[i]
+		}
 		lista = append(lista, me)
 	}
 	return lista, nil
 }
 
-func sobrescreverMonitorCSV(caminho string, lista []MonitorEntry, colunas []string) error {
-	f, err := os.Create(caminho)
-	if err != nil {
+// Escreve o resumo do monitor em CSV (separador ';')
+func escreverMonitorCSV(w io.Writer, lista []MonitorEntry) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+	if err := writer.Write(colunasMonitorCSV); err != nil {
 		return err
 	}
-	defer f.Close()
-	writer := csv.NewWriter(f)
-	writer.Comma = ';'
-	writer.Write(colunas)
 	for _, me := range lista {
 		rec := []string{
 			me.Nome,
@@ -833,11 +1048,16 @@ func sobrescreverMonitorCSV(caminho string, lista []MonitorEntry, colunas []stri
 			me.DataAtual,
 			fmt.Sprintf("%.2f", me.PrecoInicial),
 			me.DataInicial,
+			me.Loja,
+			me.LojaID,
+			me.Estado,
+		}
+		if err := writer.Write(rec); err != nil {
+			return err
 		}
-		writer.Write(rec)
 	}
 	writer.Flush()
-	return nil
+	return writer.Error()
 }
 
 // --------------------------------------------------------------------------------
@@ -849,9 +1069,85 @@ func pingHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("pong"))
 }
 
+// Status de cada carta na resposta do /scrape
+const (
+	StatusCartaOK            = "ok"
+	StatusCartaNaoEncontrada = "not_found" // a página da carta não existe
+	StatusCartaSemOfertas    = "no_nm"     // nenhuma oferta nas condições/línguas pedidas
+	StatusCartaErro          = "error"
+)
+
+// Resposta do /scrape: uma entrada por carta do request, na mesma ordem
+type ScrapeResponse struct {
+	Cartas []ResultadoCartaResposta `json:"cartas"`
+}
+
+type ResultadoCartaResposta struct {
+	Card       CardInput    `json:"card"`
+	Status     string       `json:"status"`
+	Erro       string       `json:"erro,omitempty"`
+	ClasseErro string       `json:"classe_erro,omitempty"`
+	Tentativas int          `json:"tentativas"`
+	DuracaoMs  int64        `json:"duracao_ms"`
+	Resultados []CardResult `json:"resultados"`
+}
+
+// Monta a entrada da resposta a partir do resultado do worker
+func respostaCarta(rc resultadoCarta) ResultadoCartaResposta {
+	resp := ResultadoCartaResposta{
+		Card:       rc.Card,
+		Status:     StatusCartaOK,
+		Tentativas: rc.Tentativas,
+		DuracaoMs:  rc.Duracao.Milliseconds(),
+		Resultados: rc.Resultados,
+	}
+	if resp.Resultados == nil {
+		resp.Resultados = []CardResult{}
+	}
+	switch {
+	case errors.Is(rc.Err, ErrCartaNaoEncontrada):
+		resp.Status = StatusCartaNaoEncontrada
+	case rc.Err != nil:
+		resp.Status = StatusCartaErro
+	case len(rc.Resultados) == 0:
+		resp.Status = StatusCartaSemOfertas
+	}
+	if rc.Err != nil {
+		resp.Erro, resp.ClasseErro = rc.Err.Error(), classificaErro(rc.Err)
+	}
+	return resp
+}
+
 // POST /scrape - recebe JSON com lista de CardInput e faz scraping
 type ScrapeRequest struct {
-	Cards []CardInput `json:"cards"`
+	Cards     []CardInput `json:"cards"`
+	Modo      string      `json:"modo"`      // "first" (padrão), "cheapest" ou "all"
+	Condicoes []string    `json:"condicoes"` // ex.: ["NM","SP"] (padrão: só NM)
+	Linguas   []string    `json:"linguas"`   // ex.: ["PT","EN"] (padrão: todas)
+}
+
+// Valida as opções do request e monta as OpcoesBusca.
+// Também normaliza as línguas informadas em cada carta.
+func (req *ScrapeRequest) opcoes() (OpcoesBusca, error) {
+	modo, err := normalizaModo(req.Modo)
+	if err != nil {
+		return OpcoesBusca{}, err
+	}
+	conds, err := normalizaCondicoes(req.Condicoes)
+	if err != nil {
+		return OpcoesBusca{}, err
+	}
+	linguas, err := normalizaLinguas(req.Linguas)
+	if err != nil {
+		return OpcoesBusca{}, err
+	}
+	for i := range req.Cards {
+		req.Cards[i].Linguas, err = normalizaLinguas(req.Cards[i].Linguas)
+		if err != nil {
+			return OpcoesBusca{}, fmt.Errorf("carta %s: %v", req.Cards[i].Nome, err)
+		}
+	}
+	return OpcoesBusca{Modo: modo, Condicoes: conds, Linguas: linguas}, nil
 }
 
 func scrapeHandler(w http.ResponseWriter, r *http.Request) {
@@ -869,32 +1165,50 @@ func scrapeHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Nenhuma carta enviada", http.StatusBadRequest)
 		return
 	}
-	driverPath, err := checkAndDownloadChromeDriver()
+	opts, err := req.opcoes()
 	if err != nil {
-		http.Error(w, fmt.Sprintf("erro no chromedriver: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	wd, cleanup, err := iniciarSelenium(driverPath)
+	scraper, err := novoScraper()
 	if err != nil {
-		http.Error(w, fmt.Sprintf("erro iniciar selenium: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("erro iniciar scraper: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer wd.Quit()
-	defer cleanup()
-
-	var resultados []CardResult
-	for _, c := range req.Cards {
-		ret, err2 := buscaCartaCompleta(wd, c.Nome, c.Colecao, c.Numero)
-		if err2 == nil && len(ret) > 0 {
-			resultados = append(resultados, ret...)
+	defer scraper.Fecha()
+
+	var (
+		resposta   = ScrapeResponse{Cartas: []ResultadoCartaResposta{}}
+		resultados []CardResult // ofertas gravadas e devolvidas no formato antigo (?flat=true)
+	)
+	// r.Context() é cancelado se o cliente desconectar ou o servidor desligar
+	ctx := r.Context()
+	rcs := processarCartas(ctx, scraper, req.Cards, opts, nil, nil)
+	if ctx.Err() != nil {
+		fmt.Printf("[AVISO] /scrape interrompido: %v\n", ctx.Err())
+		return
+	}
+	for _, rc := range rcs {
+		resposta.Cartas = append(resposta.Cartas, respostaCarta(rc))
+		if rc.Err != nil {
+			c := rc.Card
+			fmt.Printf("[AVISO] %s (%s - %s): %s após %d tentativa(s): %v\n", c.Nome, c.Colecao, c.Numero, classificaErro(rc.Err), rc.Tentativas, rc.Err)
+			continue
 		}
+		resultados = append(resultados, rc.Resultados...)
 	}
 	if len(resultados) > 0 {
-		outCSV := filepath.Join(config.OutputFolder, config.SaidaCSV)
-		salvarResultadosCSV(resultados, outCSV)
+		if err := store.SalvarResultados(resultados, time.Now().Format("2006-01-02 15:04:05")); err != nil {
+			fmt.Printf("[AVISO] Erro ao gravar resultados: %v\n", err)
+		}
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resultados)
+	// Compatibilidade: ?flat=true devolve o array plano de ofertas de antes
+	if flat, _ := strconv.ParseBool(r.URL.Query().Get("flat")); flat {
+		json.NewEncoder(w).Encode(resultados)
+		return
+	}
+	json.NewEncoder(w).Encode(resposta)
 }
 
 // POST /monitor - inicia (ou retoma) o monitoramento em background
@@ -914,6 +1228,11 @@ func monitorHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Nenhuma carta enviada", http.StatusBadRequest)
 		return
 	}
+	opts, err := req.opcoes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	monitorMutex.Lock()
 	if monitorRunning {
@@ -921,14 +1240,51 @@ func monitorHandler(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Monitor já está em execução.\n"))
 		return
 	}
-	monitorRunning = true
-	monitorPaused = false
+	estado := EstadoMonitor{Request: req, Ativo: true, IniciadoEm: time.Now().Format("2006-01-02 15:04:05")}
+	if err := store.SalvarMonitor(&estado); err != nil {
+		fmt.Printf("[AVISO] Erro ao gravar o estado do monitor: %v\n", err)
+	}
+	iniciarMonitor(estado, opts)
 	monitorMutex.Unlock()
 
+	w.Write([]byte("Monitoramento iniciado.\n"))
+}
+
+// Dispara o loop do monitor. Chamar com monitorMutex travado.
+func iniciarMonitor(estado EstadoMonitor, opts OpcoesBusca) {
+	ctx, cancel := context.WithCancel(ctxServidor)
+	monitorRunning = true
+	monitorPaused = estado.Pausado
+	monitorCancel = cancel
+	monitorEstado = estado
+
 	wgMonitor.Add(1)
-	go monitorLoop(req.Cards)
+	go monitorLoop(ctx, estado.Request.Cards, opts)
+}
 
-	w.Write([]byte("Monitoramento iniciado.\n"))
+// Retoma o monitor que estava ativo quando o servidor parou
+func retomarMonitor() {
+	estado, ok, err := store.MonitorAtivo()
+	if err != nil {
+		fmt.Printf("[AVISO] Erro ao ler o monitor salvo: %v\n", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	opts, err := estado.Request.opcoes()
+	if err != nil {
+		fmt.Printf("[AVISO] Monitor salvo inválido, descartando: %v\n", err)
+		estado.Ativo = false
+		if err := store.SalvarMonitor(&estado); err != nil {
+			fmt.Printf("[AVISO] Erro ao gravar o estado do monitor: %v\n", err)
+		}
+		return
+	}
+	fmt.Printf("[MONITOR] Retomando monitor iniciado em %s (%d cartas).\n", estado.IniciadoEm, len(estado.Request.Cards))
+	monitorMutex.Lock()
+	iniciarMonitor(estado, opts)
+	monitorMutex.Unlock()
 }
 
 // POST /monitor/pause - pausa ou retoma o monitor
@@ -940,6 +1296,10 @@ func monitorPauseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	monitorPaused = !monitorPaused
+	monitorEstado.Pausado = monitorPaused
+	if err := store.SalvarMonitor(&monitorEstado); err != nil {
+		fmt.Printf("[AVISO] Erro ao gravar o estado do monitor: %v\n", err)
+	}
 	if monitorPaused {
 		w.Write([]byte("Monitor pausado.\n"))
 	} else {
@@ -952,16 +1312,23 @@ func monitorStopHandler(w http.ResponseWriter, r *http.Request) {
 	monitorMutex.Lock()
 	if monitorRunning {
 		monitorRunning = false
+		monitorCancel()
+		monitorEstado.Ativo = false
+		if err := store.SalvarMonitor(&monitorEstado); err != nil {
+			fmt.Printf("[AVISO] Erro ao gravar o estado do monitor: %v\n", err)
+		}
 	}
 	monitorMutex.Unlock()
 
 	w.Write([]byte("Monitor interrompido.\n"))
 }
 
-// GET /clean - limpa histórico CSV
+// GET /clean - limpa o histórico de resultados
 func cleanHandler(w http.ResponseWriter, r *http.Request) {
-	outCSV := filepath.Join(config.OutputFolder, config.SaidaCSV)
-	limparCSV(outCSV)
+	if err := store.LimparResultados(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	w.Write([]byte("Histórico de raspagem removido.\n"))
 }
 
@@ -977,6 +1344,27 @@ func main() {
 	// Ajuste se quiser forçar uma pasta de saída
 	config.OutputFolder, _ = os.Getwd()
 
+	// Banco de dados (na primeira vez, importa os CSVs de versões antigas)
+	var err error
+	store, err = abrirStore()
+	if err != nil {
+		log.Fatalf("Erro ao abrir o banco: %v", err)
+	}
+	defer store.Fechar()
+	if err := importarArquivosLegados(store); err != nil {
+		fmt.Printf("[AVISO] Erro ao importar arquivos antigos: %v\n", err)
+	}
+
+	// Jobs de execuções anteriores: os que não terminaram são retomados
+	armazemDeJobs, err = abrirArmazemJobs(store)
+	if err != nil {
+		log.Fatalf("Erro ao carregar jobs: %v", err)
+	}
+	armazemDeJobs.retomarPendentes(ctxServidor)
+	if config.RetomarMonitor {
+		retomarMonitor()
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ping", pingHandler)
 	mux.HandleFunc("/", homeHandler) // Página inicial
@@ -985,10 +1373,19 @@ func main() {
 	mux.HandleFunc("/monitor/pause", monitorPauseHandler)
 	mux.HandleFunc("/monitor/stop", monitorStopHandler)
 	mux.HandleFunc("/clean", cleanHandler)
+	mux.HandleFunc("/health/selectors", healthSelectorsHandler)
+	mux.HandleFunc("/jobs", jobsHandler)
+	mux.HandleFunc("/jobs/", jobHandler)
+	mux.HandleFunc("/events", eventsHandler)
+	mux.HandleFunc("/ws/prices", wsPricesHandler)
+	mux.HandleFunc("/export", exportHandler)
+	mux.HandleFunc("/history", historyHandler)
 
 	srv := &http.Server{
 		Addr:    ":8080",
 		Handler: mux,
+		// Requests herdam o contexto do servidor: o desligamento aborta o scraping
+		BaseContext: func(net.Listener) context.Context { return ctxServidor },
 	}
 
 	fmt.Println("API rodando em http://localhost:8080 ... (Ctrl+C para sair)")
@@ -1003,6 +1400,9 @@ func main() {
 	// Aguardar interrupção ctrl+C
 	esperarInterrupcao()
 
+	// Cancela o scraping em andamento (requests e monitor)
+	cancelServidor()
+
 	// Se monitor estiver rodando, avisa e aguarda
 	monitorMutex.Lock()
 	if monitorRunning {
@@ -1010,10 +1410,12 @@ func main() {
 	}
 	monitorMutex.Unlock()
 	wgMonitor.Wait()
+	armazemDeJobs.aguardar()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	srv.Shutdown(ctx)
+	fecharPoolNavegador()
 	fmt.Println("Servidor finalizado.")
 }
 
@@ -1022,7 +1424,7 @@ func main() {
 // --------------------------------------------------------------------------------
 func esperarInterrupcao() {
 	c := make(chan os.Signal, 1)
-	// signal.Notify(c, syscall.SIGINT, syscall.SIGTERM) // se quiser importar "os/signal" e "syscall"
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	fmt.Println("Pressione Ctrl+C para interromper... (no Windows, feche a janela)")
 	<-c
 }
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/tebeka/selenium"
+)
+
+// --------------------------------------------------------------------------------
+// POOL DE SESSÕES DO NAVEGADOR
+// --------------------------------------------------------------------------------
+
+// Uma sessão do Chrome com seu próprio serviço do ChromeDriver
+type sessaoNavegador struct {
+	wd      selenium.WebDriver
+	cleanup func()
+	porta   int
+	paginas int // páginas visitadas desde a criação
+
+	encerrada sync.Once
+}
+
+// Sessão ainda responde? (o Chrome pode ter caído entre um uso e outro)
+func (s *sessaoNavegador) saudavel() bool {
+	_, err := s.wd.CurrentURL()
+	return err == nil
+}
+
+// Fecha o Chrome e o ChromeDriver. Pode ser chamado mais de uma vez (o
+// cancelamento encerra a sessão enquanto ela ainda está em uso).
+func (s *sessaoNavegador) encerrar() {
+	s.encerrada.Do(func() {
+		s.wd.Quit()
+		s.cleanup()
+	})
+}
+
+// Pool de sessões reutilizáveis, compartilhado por /scrape e pelo monitor.
+// Cada sessão sobe o ChromeDriver numa porta livre, então várias podem rodar
+// ao mesmo tempo. O total de sessões em uso é limitado por vagas.
+type poolSessoes struct {
+	driverPath string
+	maxPaginas int           // recicla a sessão depois de N páginas (0 = nunca)
+	vagas      chan struct{} // uma vaga por sessão em uso
+
+	mu      sync.Mutex
+	livres  []*sessaoNavegador
+	fechado bool // depois de fechar, sessões devolvidas são encerradas
+}
+
+func novoPoolSessoes(driverPath string, maxSessoes, maxPaginas int) *poolSessoes {
+	if maxSessoes <= 0 {
+		maxSessoes = 1
+	}
+	return &poolSessoes{
+		driverPath: driverPath,
+		maxPaginas: maxPaginas,
+		vagas:      make(chan struct{}, maxSessoes),
+	}
+}
+
+// Pega uma sessão livre e saudável ou abre uma nova. Bloqueia se todas
+// as vagas estiverem em uso, até o contexto ser cancelado.
+func (p *poolSessoes) pegar(ctx context.Context) (*sessaoNavegador, error) {
+	select {
+	case p.vagas <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	for {
+		p.mu.Lock()
+		if p.fechado {
+			p.mu.Unlock()
+			<-p.vagas
+			return nil, errors.New("pool do navegador encerrado")
+		}
+		var s *sessaoNavegador
+		if n := len(p.livres); n > 0 {
+			s = p.livres[n-1]
+			p.livres = p.livres[:n-1]
+		}
+		p.mu.Unlock()
+
+		if s == nil {
+			break
+		}
+		if s.saudavel() {
+			return s, nil
+		}
+		fmt.Printf("[POOL] Sessão na porta %d não responde, descartando.\n", s.porta)
+		s.encerrar()
+	}
+
+	s, err := p.novaSessao(ctx)
+	if err != nil {
+		<-p.vagas
+		return nil, err
+	}
+	return s, nil
+}
+
+// Devolve a sessão ao pool. Sessões quebradas ou que já passaram do limite
+// de páginas são encerradas em vez de reaproveitadas.
+func (p *poolSessoes) devolver(s *sessaoNavegador, quebrada bool) {
+	defer func() { <-p.vagas }()
+	if quebrada || (p.maxPaginas > 0 && s.paginas >= p.maxPaginas) {
+		fmt.Printf("[POOL] Reciclando sessão da porta %d (%d páginas).\n", s.porta, s.paginas)
+		s.encerrar()
+		return
+	}
+	p.mu.Lock()
+	if p.fechado {
+		p.mu.Unlock()
+		s.encerrar()
+		return
+	}
+	p.livres = append(p.livres, s)
+	p.mu.Unlock()
+}
+
+// Encerra as sessões livres (as em uso são encerradas ao serem devolvidas)
+func (p *poolSessoes) fechar() {
+	p.mu.Lock()
+	livres := p.livres
+	p.livres = nil
+	p.fechado = true
+	p.mu.Unlock()
+	for _, s := range livres {
+		s.encerrar()
+	}
+}
+
+func (p *poolSessoes) novaSessao(ctx context.Context) (*sessaoNavegador, error) {
+	porta, err := portaLivre()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao reservar porta p/ ChromeDriver: %v", err)
+	}
+	wd, cleanup, err := iniciarSelenium(ctx, p.driverPath, porta)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("[POOL] Nova sessão do navegador na porta %d.\n", porta)
+	return &sessaoNavegador{wd: wd, cleanup: cleanup, porta: porta}, nil
+}
+
+// Pede ao SO uma porta TCP livre para o ChromeDriver
+func portaLivre() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// Pool global, criado no primeiro uso do backend Selenium
+var (
+	poolNavegador      *poolSessoes
+	poolNavegadorMutex sync.Mutex
+)
+
+// Devolve o pool global, baixando o ChromeDriver na primeira vez.
+// Se o download falhar, a próxima chamada tenta de novo.
+func obterPoolNavegador() (*poolSessoes, error) {
+	poolNavegadorMutex.Lock()
+	defer poolNavegadorMutex.Unlock()
+	if poolNavegador != nil {
+		return poolNavegador, nil
+	}
+	driverPath, err := checkAndDownloadChromeDriver()
+	if err != nil {
+		return nil, fmt.Errorf("erro no chromedriver: %v", err)
+	}
+	poolNavegador = novoPoolSessoes(driverPath, config.MaxSessoesNavegador, config.PaginasPorSessao)
+	return poolNavegador, nil
+}
+
+// Encerra as sessões do pool global (chamado no desligamento do servidor)
+func fecharPoolNavegador() {
+	poolNavegadorMutex.Lock()
+	defer poolNavegadorMutex.Unlock()
+	if poolNavegador != nil {
+		poolNavegador.fechar()
+	}
+}
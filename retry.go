@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// --------------------------------------------------------------------------------
+// RETRY COM BACKOFF POR CLASSE DE ERRO
+// --------------------------------------------------------------------------------
+
+// Classes de erro de uma busca. Só as que têm política em config.Retry são
+// tentadas de novo.
+const (
+	ClasseRede      = "network"          // conexão recusada/caída, 5xx, 429
+	ClasseTimeout   = "timeout"          // prazo da carta ou do cliente HTTP estourou
+	ClasseSeletor   = "selector_missing" // ErroLayout
+	ClasseDriver    = "driver_crash"     // ChromeDriver/Chrome caiu ou não subiu
+	ClasseHTTP      = "http_status"      // demais status HTTP (ex.: 404)
+	ClasseCancelado = "canceled"         // request/monitor/job cancelado
+	ClasseOutro     = "other"
+)
+
+// Erro já classificado na origem (usado pelo backend Selenium, cujos erros
+// não têm tipo próprio)
+type ErroClassificado struct {
+	Classe string
+	Err    error
+}
+
+func (e *ErroClassificado) Error() string { return e.Err.Error() }
+func (e *ErroClassificado) Unwrap() error { return e.Err }
+
+// Identifica a classe do erro (vazio se err == nil)
+func classificaErro(err error) string {
+	if err == nil {
+		return ""
+	}
+	var (
+		classificado *ErroClassificado
+		layoutErr    *ErroLayout
+		statusErr    *ErroStatusHTTP
+		netErr       net.Error
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ClasseCancelado
+	case errors.As(err, &classificado):
+		return classificado.Classe
+	case errors.As(err, &layoutErr):
+		return ClasseSeletor
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ClasseTimeout
+	case errors.As(err, &statusErr):
+		if statusErr.Codigo >= 500 || statusErr.Codigo == 429 {
+			return ClasseRede
+		}
+		return ClasseHTTP
+	case errors.As(err, &netErr):
+		return ClasseRede
+	}
+	return ClasseOutro
+}
+
+// Política de retry de uma classe de erro
+type PoliticaRetry struct {
+	Tentativas int           // total de tentativas, incluindo a primeira
+	Base       time.Duration // espera antes da 2ª tentativa
+	Max        time.Duration // teto da espera
+}
+
+// Espera depois de n tentativas falhas: Base×2^(n-1), limitada a Max, com
+// jitter (entre metade e o valor cheio) para os workers não baterem juntos
+func (p PoliticaRetry) espera(n int) time.Duration {
+	d := p.Base
+	for i := 1; i < n && (p.Max <= 0 || d < p.Max); i++ {
+		d *= 2
+	}
+	if p.Max > 0 && d > p.Max {
+		d = p.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Busca a carta tentando de novo conforme a classe do erro. Retorna também
+// quantas tentativas foram feitas.
+func buscaComRetry(ctx context.Context, scraper Scraper, card CardInput, opts OpcoesBusca) ([]CardResult, int, error) {
+	for tentativas := 1; ; tentativas++ {
+		ret, err := buscaComPrazo(ctx, scraper, card, opts)
+		if err == nil || ctx.Err() != nil {
+			return ret, tentativas, err
+		}
+		classe := classificaErro(err)
+		pol, ok := config.Retry[classe]
+		if !ok || tentativas >= pol.Tentativas {
+			return ret, tentativas, err
+		}
+		espera := pol.espera(tentativas)
+		fmt.Printf("[RETRY] %s (%s - %s): %s na tentativa %d, nova tentativa em %v: %v\n",
+			card.Nome, card.Colecao, card.Numero, classe, tentativas, espera.Round(time.Millisecond), err)
+		if esperar(ctx, espera) != nil {
+			return ret, tentativas, err
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestClassificaErro(t *testing.T) {
+	casos := map[error]string{
+		nil:                      "",
+		context.Canceled:         ClasseCancelado,
+		context.DeadlineExceeded: ClasseTimeout,
+		&ErroLayout{Seletores: []string{"preco"}}:                               ClasseSeletor,
+		fmt.Errorf("busca: %w", &ErroStatusHTTP{Codigo: 503}):                   ClasseRede,
+		&ErroStatusHTTP{Codigo: 404}:                                            ClasseHTTP,
+		&ErroClassificado{Classe: ClasseDriver, Err: errors.New("sessão caiu")}: ClasseDriver,
+		errors.New("qualquer"):                                                  ClasseOutro,
+	}
+	for err, esperado := range casos {
+		if c := classificaErro(err); c != esperado {
+			t.Errorf("classificaErro(%v) = %q, esperado %q", err, c, esperado)
+		}
+	}
+}
+
+// Backend fake que falha as primeiras N buscas
+type scraperInstavel struct {
+	falhas *int
+	err    error
+}
+
+func (s scraperInstavel) Busca(ctx context.Context, card CardInput, opts OpcoesBusca) ([]CardResult, error) {
+	if *s.falhas > 0 {
+		*s.falhas--
+		return nil, s.err
+	}
+	return []CardResult{{Nome: card.Nome, Preco: 1}}, nil
+}
+
+func (scraperInstavel) Fecha() {}
+
+func TestBuscaComRetry(t *testing.T) {
+	configOriginal := config
+	t.Cleanup(func() { config = configOriginal })
+	config.Retry = map[string]PoliticaRetry{ClasseRede: {Tentativas: 3, Base: time.Millisecond}}
+
+	falhas := 2
+	ret, tentativas, err := buscaComRetry(context.Background(), scraperInstavel{&falhas, &ErroStatusHTTP{Codigo: 502}}, charizard, OpcoesBusca{})
+	if err != nil || tentativas != 3 || len(ret) != 1 {
+		t.Errorf("rede: ret %v, tentativas %d, err %v", ret, tentativas, err)
+	}
+
+	// Sem política para a classe: não tenta de novo
+	falhas = 2
+	_, tentativas, err = buscaComRetry(context.Background(), scraperInstavel{&falhas, &ErroStatusHTTP{Codigo: 404}}, charizard, OpcoesBusca{})
+	if classificaErro(err) != ClasseHTTP || tentativas != 1 {
+		t.Errorf("404: tentativas %d, err %v", tentativas, err)
+	}
+
+	// Esgota as tentativas e devolve o último erro
+	falhas = 5
+	_, tentativas, err = buscaComRetry(context.Background(), scraperInstavel{&falhas, &ErroStatusHTTP{Codigo: 500}}, charizard, OpcoesBusca{})
+	if classificaErro(err) != ClasseRede || tentativas != 3 {
+		t.Errorf("esgotado: tentativas %d, err %v", tentativas, err)
+	}
+}
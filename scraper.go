@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// --------------------------------------------------------------------------------
+// BACKENDS DE SCRAPING
+// --------------------------------------------------------------------------------
+
+// Scraper é a abstração usada pelos handlers e pelo monitor para buscar cartas.
+// Cada backend (Selenium, HTTP puro, fake de testes...) implementa esta interface.
+type Scraper interface {
+	// Busca retorna as ofertas encontradas para a carta informada. Deve
+	// desistir e retornar ctx.Err() quando o contexto for cancelado.
+	Busca(ctx context.Context, card CardInput, opts OpcoesBusca) ([]CardResult, error)
+	// Fecha libera os recursos do backend (navegador, serviço, conexões)
+	Fecha()
+}
+
+// Modos de seleção das ofertas de uma carta
+const (
+	ModoPrimeira   = "first"    // primeira oferta aceita encontrada (comportamento original)
+	ModoMaisBarata = "cheapest" // oferta aceita de menor preço total (com frete)
+	ModoTodas      = "all"      // todas as ofertas aceitas, uma por loja
+)
+
+// Opções que afetam como as ofertas de uma carta são coletadas
+type OpcoesBusca struct {
+	Modo      string
+	Condicoes []Condicao // condições aceitas (padrão: só NM)
+	Linguas   []string   // línguas aceitas (vazio: todas); CardInput.Linguas tem prioridade
+}
+
+// Indica se a condição passa no filtro do request
+func (o OpcoesBusca) aceitaCondicao(c Condicao) bool {
+	if len(o.Condicoes) == 0 {
+		return c == CondicaoNM
+	}
+	for _, aceita := range o.Condicoes {
+		if c == aceita {
+			return true
+		}
+	}
+	return false
+}
+
+// Indica se a língua passa na preferência da carta ou, sem ela, na do request
+func (o OpcoesBusca) aceitaLingua(card CardInput, lingua string) bool {
+	prefs := o.Linguas
+	if len(card.Linguas) > 0 {
+		prefs = card.Linguas
+	}
+	if len(prefs) == 0 {
+		return true
+	}
+	for _, p := range prefs {
+		if lingua == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Valida o modo e aplica o padrão ("first") quando vier vazio
+func normalizaModo(modo string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(modo)) {
+	case "", ModoPrimeira:
+		return ModoPrimeira, nil
+	case ModoMaisBarata:
+		return ModoMaisBarata, nil
+	case ModoTodas:
+		return ModoTodas, nil
+	default:
+		return "", fmt.Errorf("modo inválido: %q (use first, cheapest ou all)", modo)
+	}
+}
+
+// Aplica o modo sobre a lista de ofertas coletadas
+func selecionaOfertas(ofertas []CardResult, modo string) []CardResult {
+	if len(ofertas) == 0 {
+		return ofertas
+	}
+	switch modo {
+	case ModoTodas:
+		return ofertas
+	case ModoMaisBarata:
+		return []CardResult{ofertas[indiceMaisBarata(ofertas)]}
+	default:
+		return ofertas[:1]
+	}
+}
+
+// Posição da oferta de menor preço total. Empates são decididos pelo preço
+// e pela loja, para a escolha não depender da ordem da página.
+func indiceMaisBarata(ofertas []CardResult) int {
+	menor := 0
+	for i, o := range ofertas[1:] {
+		m := ofertas[menor]
+		if o.PrecoTotal < m.PrecoTotal ||
+			o.PrecoTotal == m.PrecoTotal && (o.Preco < m.Preco || o.Preco == m.Preco && o.LojaID+o.Loja < m.LojaID+m.Loja) {
+			menor = i + 1
+		}
+	}
+	return menor
+}
+
+// novoScraper cria o backend de scraping a ser usado por /scrape e pelo monitor.
+// É uma variável para que os testes possam trocar por um backend fake.
+var novoScraper = func() (Scraper, error) {
+	switch config.ModoSnapshot {
+	case SnapshotDesligado, SnapshotGravar:
+	case SnapshotReplay:
+		// No replay as páginas vêm dos snapshots, então não precisa de navegador
+		return novoHTTPScraper(), nil
+	default:
+		return nil, fmt.Errorf("modo de snapshot desconhecido: %q", config.ModoSnapshot)
+	}
+
+	switch config.Backend {
+	case "http":
+		return novoHTTPScraper(), nil
+	case "selenium":
+		s, err := novoSeleniumScraper()
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("backend de scraping desconhecido: %q", config.Backend)
+	}
+}
+
+// Backend baseado em Selenium + ChromeDriver. As sessões do navegador vêm
+// do pool global, então vários scrapers podem rodar ao mesmo tempo.
+type seleniumScraper struct {
+	pool *poolSessoes
+}
+
+// Baixa (se preciso) o ChromeDriver e prepara o pool de sessões
+func novoSeleniumScraper() (*seleniumScraper, error) {
+	pool, err := obterPoolNavegador()
+	if err != nil {
+		return nil, err
+	}
+	return &seleniumScraper{pool: pool}, nil
+}
+
+func (s *seleniumScraper) Busca(ctx context.Context, card CardInput, opts OpcoesBusca) ([]CardResult, error) {
+	sessao, err := s.pool.pegar(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, &ErroClassificado{Classe: ClasseDriver, Err: err}
+	}
+
+	// As chamadas ao WebDriver não aceitam contexto: a busca roda à parte e,
+	// se o contexto cair, a sessão é encerrada para destravar a chamada pendente
+	type retorno struct {
+		ret []CardResult
+		err error
+	}
+	fim := make(chan retorno, 1)
+	go func() {
+		ret, err := buscaCartaCompleta(ctx, sessao.wd, card, opts)
+		fim <- retorno{ret, err}
+	}()
+	var r retorno
+	select {
+	case r = <-fim:
+	case <-ctx.Done():
+		sessao.encerrar()
+		<-fim
+		s.pool.devolver(sessao, true)
+		return nil, ctx.Err()
+	}
+	sessao.paginas++
+
+	// Erro de layout não é culpa da sessão; os demais (ex.: wd.Get) podem ser.
+	// Se a sessão não responde mais, o driver caiu; senão, trata como rede.
+	var layoutErr *ErroLayout
+	quebrada := r.err != nil && !errors.As(r.err, &layoutErr)
+	if quebrada && ctx.Err() == nil {
+		classe := ClasseRede
+		if !sessao.saudavel() {
+			classe = ClasseDriver
+		}
+		r.err = &ErroClassificado{Classe: classe, Err: r.err}
+	}
+	s.pool.devolver(sessao, quebrada)
+	return r.ret, r.err
+}
+
+// As sessões ficam no pool para o próximo uso
+func (s *seleniumScraper) Fecha() {}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// --------------------------------------------------------------------------------
+// BACKEND HTTP (sem navegador)
+// --------------------------------------------------------------------------------
+
+// Backend que baixa a página da carta com net/http e lê as lojas direto do HTML
+type httpScraper struct {
+	client *http.Client
+}
+
+func novoHTTPScraper() *httpScraper {
+	return &httpScraper{
+		client: &http.Client{
+			Timeout:   config.TimeoutHTTP,
+			Transport: transporteSnapshot(),
+		},
+	}
+}
+
+func (s *httpScraper) Busca(ctx context.Context, card CardInput, opts OpcoesBusca) ([]CardResult, error) {
+	url := montaURLCarta(card.Nome, card.Colecao, card.Numero)
+	doc, err := s.baixaPagina(ctx, url)
+	if err != nil {
+		return []CardResult{}, err
+	}
+	return extraiOfertasHTML(doc, card, opts)
+}
+
+func (s *httpScraper) Fecha() {
+	s.client.CloseIdleConnections()
+}
+
+// Faz o GET da página e devolve o documento HTML já parseado. O request é
+// abortado se o contexto for cancelado.
+func (s *httpScraper) baixaPagina(ctx context.Context, url string) (*goquery.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36")
+	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9")
+
+	// No replay não há site do outro lado, então não precisa esperar a vez
+	if config.ModoSnapshot != SnapshotReplay {
+		if err := limitadorPolidez.esperarVez(ctx, url); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &ErroStatusHTTP{Codigo: resp.StatusCode}
+	}
+	return goquery.NewDocumentFromReader(resp.Body)
+}
+
+// A página da carta não existe no site (404)
+var ErrCartaNaoEncontrada = errors.New("carta não encontrada")
+
+// Resposta HTTP diferente de 200 ao baixar a página
+type ErroStatusHTTP struct {
+	Codigo int
+}
+
+func (e *ErroStatusHTTP) Error() string {
+	return fmt.Sprintf("status code: %d", e.Codigo)
+}
+
+// errors.Is(err, ErrCartaNaoEncontrada) vale para o 404
+func (e *ErroStatusHTTP) Is(alvo error) bool {
+	return alvo == ErrCartaNaoEncontrada && e.Codigo == http.StatusNotFound
+}
+
+// Percorre as lojas (#marketplace-stores .store) e devolve as ofertas aceitas conforme o modo.
+// Retorna ErroLayout se a página não tiver a estrutura esperada.
+func extraiOfertasHTML(doc *goquery.Document, card CardInput, opts OpcoesBusca) ([]CardResult, error) {
+	resultados := []CardResult{}
+	p := seletores()
+
+	container := doc.Find(p.ContainerLojas)
+	if container.Length() == 0 {
+		return resultados, erroSemContainer(doc.Find(p.MarcadorPagina).Length() > 0)
+	}
+	stores := container.Find(p.Loja)
+	if stores.Length() == 0 {
+		fmt.Println("[AVISO] Nenhum vendedor encontrado.")
+		return resultados, nil
+	}
+
+	var diag diagnosticoLojas
+	stores.EachWithBreak(func(_ int, store *goquery.Selection) bool {
+		diag.lojas++
+		lingua, cond := extraiLinguaECondicaoHTML(store, p)
+		if cond != "" {
+			diag.comCondicao++
+		}
+		if !opts.aceitaCondicao(cond) || !opts.aceitaLingua(card, lingua) {
+			return true
+		}
+		diag.aceitas++
+		preco := convertePrecoParaFloat(store.Find(p.Preco).First().Text())
+		if preco == 0 {
+			return true
+		}
+		diag.comPreco++
+		oferta := CardResult{
+			Nome:       card.Nome,
+			Colecao:    card.Colecao,
+			Numero:     card.Numero,
+			Condicao:   cond,
+			Quantidade: parseEstoque(store.Find(p.Estoque).First().Text()),
+			Preco:      preco,
+			PrecoTotal: preco,
+			Lingua:     lingua,
+		}
+		href, _ := store.Find(p.LinkLoja).First().Attr("href")
+		dataID, _ := store.Attr(p.AtributoIDLoja)
+		preencheLoja(&oferta, store.Find(p.NomeLoja).First().Text(), href, dataID,
+			store.Find(p.LocalLoja).First().Text())
+		aplicaPrecoTotal(&oferta, card, store.Find(p.Frete).First().Text())
+		resultados = append(resultados, oferta)
+		return opts.Modo != ModoPrimeira // no modo "first", se já achou 1, pode sair
+	})
+
+	if err := diag.erro(); err != nil {
+		return resultados, err
+	}
+	return selecionaOfertas(resultados, opts.Modo), nil
+}
+
+// Versão HTML de extraiLinguaECondicao
+func extraiLinguaECondicaoHTML(store *goquery.Selection, p PerfilSeletores) (string, Condicao) {
+	lingua := ""
+	var condicao Condicao
+	infos := store.Find(p.InfosQualidadeLingua).First()
+	if infos.Length() == 0 {
+		return lingua, condicao
+	}
+	var titulos []string
+	infos.Find(p.Bandeira).Each(func(_ int, img *goquery.Selection) {
+		title, _ := img.Attr("title")
+		titulos = append(titulos, title)
+	})
+	lingua = escolheLingua(titulos)
+	infos.Find(p.Qualidade).EachWithBreak(func(_ int, q *goquery.Selection) bool {
+		title, _ := q.Attr("title")
+		if c, ok := parseCondicao(title); ok {
+			condicao = c
+			return false
+		}
+		return true
+	})
+	return lingua, condicao
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// --------------------------------------------------------------------------------
+// PERFIL DE SELETORES CSS DO SITE DA LIGA
+// --------------------------------------------------------------------------------
+
+// PerfilSeletores reúne os seletores CSS usados para ler a página da carta.
+// Fica num arquivo JSON (config.ArquivoSeletores) para que uma mudança de
+// layout seja corrigida sem recompilar; campos ausentes no arquivo usam o padrão.
+type PerfilSeletores struct {
+	Versao string `json:"versao"`
+
+	// Página da carta
+	BannerCookies      string `json:"banner_cookies"`
+	BotaoBannerCookies string `json:"botao_banner_cookies"` // relativo ao banner
+	MarcadorPagina     string `json:"marcador_pagina"`      // existe em toda página de carta, com ou sem vendedores
+	ContainerLojas     string `json:"container_lojas"`
+	Loja               string `json:"loja"` // relativo ao container
+
+	// Bloco de cada loja (relativos a Loja)
+	InfosQualidadeLingua string `json:"infos_qualidade_lingua"`
+	Qualidade            string `json:"qualidade"` // relativo às infos, lê o atributo title
+	Bandeira             string `json:"bandeira"`  // relativo às infos, lê o atributo title
+	Preco                string `json:"preco"`
+	Estoque              string `json:"estoque"`
+	Frete                string `json:"frete"`
+	NomeLoja             string `json:"nome_loja"`
+	LinkLoja             string `json:"link_loja"`
+	LocalLoja            string `json:"local_loja"`
+	AtributoIDLoja       string `json:"atributo_id_loja"`
+	BotaoComprar         string `json:"botao_comprar"`
+
+	// Modal do carrinho (fallback do Selenium)
+	IconeCarrinho       string `json:"icone_carrinho"`
+	BotaoVerCarrinho    string `json:"botao_ver_carrinho"`
+	ItensCarrinho       string `json:"itens_carrinho"`
+	LinhaCarrinho       string `json:"linha_carrinho"` // relativo aos itens
+	TituloItemCarrinho  string `json:"titulo_item_carrinho"`
+	EstoqueItemCarrinho string `json:"estoque_item_carrinho"`
+	PrecoItemCarrinho   string `json:"preco_item_carrinho"`
+	RemoverItemCarrinho string `json:"remover_item_carrinho"`
+}
+
+// Perfil embutido, usado quando não há arquivo (layout atual da Liga)
+var perfilSeletoresPadrao = PerfilSeletores{
+	Versao: "1",
+
+	BannerCookies:      "#lgpd-cookie",
+	BotaoBannerCookies: "button",
+	MarcadorPagina:     "#card-info",
+	ContainerLojas:     "#marketplace-stores",
+	Loja:               ".store",
+
+	InfosQualidadeLingua: ".infos-quality-and-language.desktop-only",
+	Qualidade:            ".quality",
+	Bandeira:             "img",
+	Preco:                ".price",
+	Estoque:              ".store-stock",
+	Frete:                ".store-shipping",
+	NomeLoja:             ".store-name",
+	LinkLoja:             ".store-name a",
+	LocalLoja:            ".store-location",
+	AtributoIDLoja:       "data-id",
+	BotaoComprar:         "div.btn-green.cursor-pointer",
+
+	IconeCarrinho:       "div.cart-icon-container.icon-container",
+	BotaoVerCarrinho:    "a.btn-view-cart",
+	ItensCarrinho:       "div.itens",
+	LinhaCarrinho:       "div.row",
+	TituloItemCarrinho:  "p.cardtitle a",
+	EstoqueItemCarrinho: "div.item-estoque",
+	PrecoItemCarrinho:   "div.preco-total.item-total",
+	RemoverItemCarrinho: "div.btn-circle.remove.delete.item-delete",
+}
+
+// Estado do perfil carregado (com hot reload pela data de modificação do arquivo)
+var (
+	perfilMutex      sync.Mutex
+	perfilAtual      = perfilSeletoresPadrao
+	perfilArquivo    string    // arquivo de onde veio o perfil atual
+	perfilModificado time.Time // ModTime do arquivo no último carregamento
+	perfilVerificado time.Time // última vez que o arquivo foi checado
+)
+
+// Intervalo mínimo entre checagens do arquivo de seletores
+const intervaloRecargaSeletores = time.Second
+
+// seletores devolve o perfil em uso, recarregando o arquivo se ele mudou.
+// Se o arquivo novo for inválido, mantém o perfil anterior.
+func seletores() PerfilSeletores {
+	perfilMutex.Lock()
+	defer perfilMutex.Unlock()
+
+	arquivo := config.ArquivoSeletores
+	if arquivo == perfilArquivo && time.Since(perfilVerificado) < intervaloRecargaSeletores {
+		return perfilAtual
+	}
+	perfilVerificado = time.Now()
+
+	info, err := os.Stat(arquivo)
+	if arquivo == "" || err != nil {
+		// Sem arquivo: volta para o perfil embutido
+		if perfilArquivo != "" {
+			fmt.Println("[SELETORES] Arquivo de seletores ausente, usando perfil padrão.")
+		}
+		perfilAtual, perfilArquivo, perfilModificado = perfilSeletoresPadrao, "", time.Time{}
+		return perfilAtual
+	}
+	if arquivo == perfilArquivo && info.ModTime().Equal(perfilModificado) {
+		return perfilAtual
+	}
+
+	perfil, err := carregarPerfilSeletores(arquivo)
+	if err != nil {
+		fmt.Printf("[SELETORES] ERRO ao carregar %s (mantendo v%s): %v\n", arquivo, perfilAtual.Versao, err)
+		perfilArquivo, perfilModificado = arquivo, info.ModTime()
+		return perfilAtual
+	}
+	perfilAtual, perfilArquivo, perfilModificado = perfil, arquivo, info.ModTime()
+	fmt.Printf("[SELETORES] Perfil v%s carregado de %s\n", perfil.Versao, arquivo)
+	return perfilAtual
+}
+
+// Lê o perfil do arquivo JSON, completando campos ausentes com o padrão
+func carregarPerfilSeletores(caminho string) (PerfilSeletores, error) {
+	perfil := perfilSeletoresPadrao
+	dados, err := os.ReadFile(caminho)
+	if err != nil {
+		return perfil, err
+	}
+	if err := json.Unmarshal(dados, &perfil); err != nil {
+		return perfil, err
+	}
+	if perfil.Versao == "" {
+		return perfil, fmt.Errorf("perfil sem versão")
+	}
+	// Nenhum seletor pode ficar vazio (ex.: "preco": "" no arquivo)
+	v := reflect.ValueOf(perfil)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).String() == "" {
+			return perfil, fmt.Errorf("seletor %q vazio", v.Type().Field(i).Tag.Get("json"))
+		}
+	}
+	return perfil, nil
+}
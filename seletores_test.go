@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Força a próxima chamada de seletores() a olhar o arquivo de novo
+func expiraCacheSeletores(t *testing.T, arquivo string, mod time.Time) {
+	t.Helper()
+	if err := os.Chtimes(arquivo, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+	perfilMutex.Lock()
+	perfilVerificado = time.Time{}
+	perfilMutex.Unlock()
+}
+
+func TestPerfilSeletoresHotReload(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	arquivo := filepath.Join(t.TempDir(), "seletores.json")
+	config.ArquivoSeletores = arquivo
+
+	// Layout "quebrado": o preço mudou de classe
+	os.WriteFile(arquivo, []byte(`{"versao": "2", "preco": ".preco-novo"}`), 0644)
+	expiraCacheSeletores(t, arquivo, time.Now().Add(-time.Minute))
+	if p := seletores(); p.Versao != "2" || p.Preco != ".preco-novo" || p.Loja != ".store" {
+		t.Fatalf("perfil carregado = %+v", p)
+	}
+	if _, res := postScrape(t, ScrapeRequest{Cards: []CardInput{charizard}}); len(res) != 0 {
+		t.Errorf("com seletor de preço errado não deveria achar ofertas: %+v", res)
+	}
+
+	// Corrige o arquivo sem reiniciar
+	os.WriteFile(arquivo, []byte(`{"versao": "3", "preco": ".price"}`), 0644)
+	expiraCacheSeletores(t, arquivo, time.Now())
+	status, res := postScrape(t, ScrapeRequest{Cards: []CardInput{charizard}})
+	if status != http.StatusOK || len(res) != 1 || res[0].Preco != 350 {
+		t.Errorf("depois do reload: status %d, resultados %+v", status, res)
+	}
+
+	// Arquivo inválido mantém o perfil anterior
+	os.WriteFile(arquivo, []byte(`{"versao": "4", "preco": ""}`), 0644)
+	expiraCacheSeletores(t, arquivo, time.Now().Add(time.Minute))
+	if p := seletores(); p.Versao != "3" {
+		t.Errorf("perfil inválido não deveria substituir o v3, veio v%s", p.Versao)
+	}
+}
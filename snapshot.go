@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --------------------------------------------------------------------------------
+// GRAVAÇÃO E REPLAY DAS PÁGINAS (snapshots)
+// --------------------------------------------------------------------------------
+
+// Modos de snapshot (config.ModoSnapshot)
+const (
+	SnapshotDesligado = ""
+	SnapshotGravar    = "record" // salva o HTML de cada página visitada
+	SnapshotReplay    = "replay" // serve as páginas salvas no lugar do site
+)
+
+// Nome do arquivo de snapshot de uma carta: <colecao>_<numero>.html
+// (mesmo formato das fixtures em testdata/liga, p/ virar teste de regressão)
+func chaveSnapshot(colecao, numero string) string {
+	limpa := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '/' || r == '\\' || r == ':' || r == ' ' || r == '.' {
+				return '-'
+			}
+			return r
+		}, strings.TrimSpace(s))
+	}
+	return limpa(colecao) + "_" + limpa(numero) + ".html"
+}
+
+// Mesmo que chaveSnapshot, a partir da URL montada por montaURLCarta
+func chaveSnapshotURL(u *url.URL) string {
+	q := u.Query()
+	return chaveSnapshot(q.Get("ed"), q.Get("num"))
+}
+
+// Salva o HTML de uma página na pasta de snapshots
+func gravaSnapshot(chave string, html []byte) error {
+	if err := os.MkdirAll(config.PastaSnapshots, 0755); err != nil {
+		return err
+	}
+	caminho := filepath.Join(config.PastaSnapshots, chave)
+	err := gravarArquivoAtomico(caminho, func(w io.Writer) error {
+		_, err := w.Write(html)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[SNAPSHOT] Página salva em %s\n", caminho)
+	return nil
+}
+
+// Transport que grava o corpo de cada resposta OK antes de entregá-lo ao scraper
+type transporteGravacao struct {
+	base http.RoundTripper
+}
+
+func (t transporteGravacao) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	corpo, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := gravaSnapshot(chaveSnapshotURL(req.URL), corpo); err != nil {
+		fmt.Printf("[SNAPSHOT] ERRO ao gravar: %v\n", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(corpo))
+	return resp, nil
+}
+
+// Transport que responde com os snapshots salvos, sem acessar a rede.
+// Carta sem snapshot responde 404.
+type transporteReplay struct{}
+
+func (transporteReplay) RoundTrip(req *http.Request) (*http.Response, error) {
+	caminho := filepath.Join(config.PastaSnapshots, chaveSnapshotURL(req.URL))
+	html, err := os.ReadFile(caminho)
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusNotFound
+		html = []byte("snapshot não encontrado: " + caminho)
+	}
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(html)),
+		ContentLength: int64(len(html)),
+		Request:       req,
+	}, nil
+}
+
+// Transport do backend HTTP conforme o modo de snapshot
+func transporteSnapshot() http.RoundTripper {
+	switch config.ModoSnapshot {
+	case SnapshotGravar:
+		return transporteGravacao{base: http.DefaultTransport}
+	case SnapshotReplay:
+		return transporteReplay{}
+	default:
+		return http.DefaultTransport
+	}
+}
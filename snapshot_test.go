@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotGravacao(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	config.ModoSnapshot = SnapshotGravar
+	config.PastaSnapshots = filepath.Join(t.TempDir(), "snapshots")
+
+	if status, res := postScrape(t, ScrapeRequest{Cards: []CardInput{charizard}}); status != http.StatusOK || len(res) != 1 {
+		t.Fatalf("status = %d, resultados = %+v", status, res)
+	}
+
+	gravado, err := os.ReadFile(filepath.Join(config.PastaSnapshots, "OBF_125.html"))
+	if err != nil {
+		t.Fatalf("snapshot não foi gravado: %v", err)
+	}
+	original, _ := os.ReadFile(filepath.Join(pastaFixturesLiga, "OBF_125.html"))
+	if !bytes.Equal(gravado, original) {
+		t.Error("snapshot gravado difere da página servida")
+	}
+}
+
+func TestSnapshotReplay(t *testing.T) {
+	iniciaMarketplaceFixture(t)
+	// Nada responde nesse endereço: tudo tem que vir dos snapshots
+	config.Website = "http://liga.invalid/"
+	config.ModoSnapshot = SnapshotReplay
+	config.PastaSnapshots = pastaFixturesLiga
+
+	status, res := postScrape(t, ScrapeRequest{Cards: []CardInput{charizard}, Modo: "cheapest"})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(res) != 1 || res[0].Loja != "Loja Beta" || res[0].Preco != 320 {
+		t.Errorf("resultados = %+v", res)
+	}
+}
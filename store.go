@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// --------------------------------------------------------------------------------
+// PERSISTÊNCIA
+// --------------------------------------------------------------------------------
+
+// Store guarda tudo o que o sistema persiste: resultados do /scrape e dos
+// jobs, histórico do monitor, estado do monitor e jobs. As implementações
+// precisam aceitar chamadas concorrentes (/scrape, monitor e jobs ao mesmo tempo).
+type Store interface {
+	// Resultados de scraping, na ordem em que foram gravados
+	SalvarResultados(resultados []CardResult, dataStr string) error
+	Resultados() ([]CardResult, error)
+	LimparResultados() error
+
+	// Histórico do monitor (append-only), na ordem de gravação
+	AnexarObservacoes(obs []Observacao) error
+	Observacoes(filtro FiltroObservacoes) ([]Observacao, error)
+	// Preço da última observação selecionada da carta (ok = false se não há)
+	UltimoPreco(nome, colecao, numero string) (preco float64, ok bool, err error)
+
+	// Jobs assíncronos (SalvarJob insere ou atualiza pelo ID)
+	SalvarJob(job *Job) error
+	Jobs() ([]*Job, error)
+
+	// Monitor (SalvarMonitor insere se m.ID == 0 e preenche o ID)
+	SalvarMonitor(m *EstadoMonitor) error
+	MonitorAtivo() (EstadoMonitor, bool, error)
+
+	Fechar() error
+}
+
+// Filtro do histórico; campos vazios não filtram. De/Ate comparam com
+// Observacao.Data ("2006-01-02 15:04:05"), inclusive.
+type FiltroObservacoes struct {
+	Nome, Colecao, Numero string
+	De, Ate               string
+}
+
+// Aceita a observação? (usado pelas implementações sem consulta própria)
+func (f FiltroObservacoes) aceita(o Observacao) bool {
+	if f.Nome != "" && chaveCarta(f.Nome, f.Colecao, f.Numero) != chaveCarta(o.Nome, o.Colecao, o.Numero) {
+		return false
+	}
+	if f.De != "" && o.Data < f.De {
+		return false
+	}
+	if f.Ate != "" && o.Data > f.Ate {
+		return false
+	}
+	return true
+}
+
+// Monitor registrado (o que foi pedido em POST /monitor). Um monitor ativo
+// é retomado quando o servidor reinicia.
+type EstadoMonitor struct {
+	ID         int64         `json:"id"`
+	Request    ScrapeRequest `json:"request"`
+	Ativo      bool          `json:"ativo"`
+	Pausado    bool          `json:"pausado"`
+	IniciadoEm string        `json:"iniciado_em"`
+}
+
+// Implementações do Store (config.Armazenamento)
+const (
+	ArmazenamentoSQLite = "sqlite" // banco em config.ArquivoBanco
+	ArmazenamentoCSV    = "csv"    // arquivos CSV das versões antigas
+	ArmazenamentoJSONL  = "jsonl"  // um arquivo JSON Lines por tipo de dado
+)
+
+// Store em uso, aberto no main
+var store Store
+
+func abrirStore() (Store, error) {
+	switch config.Armazenamento {
+	case ArmazenamentoSQLite:
+		return abrirSQLiteStore(filepath.Join(config.OutputFolder, config.ArquivoBanco))
+	case ArmazenamentoCSV:
+		return abrirCSVStore(config.OutputFolder)
+	case ArmazenamentoJSONL:
+		return abrirJSONLStore(config.OutputFolder)
+	}
+	return nil, fmt.Errorf("config.Armazenamento inválido: %q (use %s, %s ou %s)",
+		config.Armazenamento, ArmazenamentoSQLite, ArmazenamentoCSV, ArmazenamentoJSONL)
+}
+
+// Importa os arquivos das versões que gravavam tudo em CSV/JSON, se o store
+// ainda estiver vazio. Os arquivos antigos não são alterados.
+func importarArquivosLegados(st Store) error {
+	obs, err := st.Observacoes(FiltroObservacoes{})
+	if err != nil {
+		return err
+	}
+	if len(obs) == 0 {
+		legado, err := carregarHistoricoCSV(filepath.Join(config.OutputFolder, config.HistoricoCSV))
+		if err != nil {
+			return err
+		}
+		if legado == nil {
+			legado = migrarResumoLegado(filepath.Join(config.OutputFolder, config.MonitorCSV))
+		}
+		if len(legado) > 0 {
+			if err := st.AnexarObservacoes(legado); err != nil {
+				return err
+			}
+			fmt.Printf("[STORE] %d observações importadas dos CSVs antigos.\n", len(legado))
+		}
+	}
+
+	jobs, err := st.Jobs()
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		dados, err := os.ReadFile(filepath.Join(config.OutputFolder, config.ArquivoJobs))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var lista []*Job
+		if err := json.Unmarshal(dados, &lista); err != nil {
+			return fmt.Errorf("%s: %v", config.ArquivoJobs, err)
+		}
+		for _, j := range lista {
+			if err := st.SalvarJob(j); err != nil {
+				return err
+			}
+		}
+		if len(lista) > 0 {
+			fmt.Printf("[STORE] %d jobs importados de %s.\n", len(lista), config.ArquivoJobs)
+		}
+	}
+	return nil
+}
+
+// --------------------------------------------------------------------------------
+// GET /export - CSV gerado a partir do store
+// --------------------------------------------------------------------------------
+
+// GET /export?tipo=resultados|historico|monitor (padrão: resultados)
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Use GET para /export", http.StatusMethodNotAllowed)
+		return
+	}
+	tipo := r.URL.Query().Get("tipo")
+	if tipo == "" {
+		tipo = "resultados"
+	}
+
+	var (
+		nomeArq  string
+		escrever func() error
+	)
+	switch tipo {
+	case "resultados":
+		resultados, err := store.Resultados()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		nomeArq = config.SaidaCSV
+		escrever = func() error { return escreverResultadosCSV(w, resultados, true) }
+	case "historico", "monitor":
+		obs, err := store.Observacoes(FiltroObservacoes{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if tipo == "historico" {
+			nomeArq = config.HistoricoCSV
+			escrever = func() error { return escreverHistoricoCSV(w, obs, true) }
+		} else {
+			nomeArq = config.MonitorCSV
+			escrever = func() error { return escreverMonitorCSV(w, resumoHistorico(obs)) }
+		}
+	default:
+		http.Error(w, fmt.Sprintf("tipo inválido %q (use resultados, historico ou monitor)", tipo), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nomeArq))
+	if err := escrever(); err != nil {
+		fmt.Printf("[AVISO] /export %s: %v\n", tipo, err)
+	}
+}
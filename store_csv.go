@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// --------------------------------------------------------------------------------
+// STORE CSV (formato das versões antigas)
+// --------------------------------------------------------------------------------
+
+// Estado do monitor nos stores de arquivo (só o último monitor registrado)
+const arquivoMonitorAtivo = "monitor_ativo.json"
+
+// Grava nos mesmos arquivos de antes do banco: config.SaidaCSV,
+// config.HistoricoCSV (mais o resumo em config.MonitorCSV) e config.ArquivoJobs.
+// O CSV de resultados não tem coluna de data, então dataStr é descartado.
+// Cada arquivo é acessado com comTrava; os que são reescritos inteiros
+// (resumo, jobs, monitor) passam por gravarArquivoAtomico.
+type csvStore struct {
+	mu     sync.Mutex
+	pasta  string
+	resumo resumoIncremental // de config.HistoricoCSV, para não relê-lo a cada gravação
+}
+
+func abrirCSVStore(pasta string) (*csvStore, error) {
+	if err := os.MkdirAll(pasta, 0755); err != nil {
+		return nil, err
+	}
+	return &csvStore{pasta: pasta}, nil
+}
+
+func (s *csvStore) caminho(nome string) string {
+	return filepath.Join(s.pasta, nome)
+}
+
+func (s *csvStore) Fechar() error {
+	return nil
+}
+
+func (s *csvStore) SalvarResultados(resultados []CardResult, dataStr string) error {
+	if len(resultados) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caminho := s.caminho(config.SaidaCSV)
+	return comTrava(caminho, func() error {
+		if err := descartarLinhaCortada(caminho); err != nil {
+			return err
+		}
+		if err := migrarResultadosCSV(caminho); err != nil {
+			return err
+		}
+		return anexarArquivo(caminho, func(w io.Writer, novo bool) error {
+			return escreverResultadosCSV(w, resultados, novo)
+		})
+	})
+}
+
+// Arquivos de versões antigas têm menos colunas no cabeçalho. Antes de
+// anexar linhas no formato atual, o arquivo é reescrito com o cabeçalho
+// novo (chamar com a trava do arquivo).
+func migrarResultadosCSV(caminho string) error {
+	f, err := os.Open(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(f)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	cols, err := reader.Read()
+	f.Close()
+	if err == io.EOF || slices.Equal(cols, colunasResultadosCSV) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	lista, err := carregarResultadosCSV(caminho)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[STORE] %s com cabeçalho antigo, reescrevendo com %d colunas.\n", filepath.Base(caminho), len(colunasResultadosCSV))
+	return gravarArquivoAtomico(caminho, func(w io.Writer) error {
+		return escreverResultadosCSV(w, lista, true)
+	})
+}
+
+func (s *csvStore) Resultados() ([]CardResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lista []CardResult
+	caminho := s.caminho(config.SaidaCSV)
+	err := comTrava(caminho, func() (err error) {
+		lista, err = carregarResultadosCSV(caminho)
+		return err
+	})
+	return lista, err
+}
+
+func (s *csvStore) LimparResultados() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caminho := s.caminho(config.SaidaCSV)
+	return comTrava(caminho, func() error {
+		err := os.Remove(caminho)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	})
+}
+
+// Lê o CSV de resultados (vazio se o arquivo não existe)
+func carregarResultadosCSV(caminho string) ([]CardResult, error) {
+	f, err := os.Open(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1 // cabeçalho antigo com linhas novas, mais longas, ou linha cortada
+	cols, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	colIndex := make(map[string]int)
+	for i, c := range cols {
+		colIndex[strings.ToLower(strings.TrimSpace(c))] = i
+	}
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var lista []CardResult
+	for _, line := range lines {
+		if len(line) < len(cols) {
+			continue
+		}
+		campo := func(nome string) string {
+			if i, ok := colIndex[nome]; ok {
+				return line[i]
+			}
+			return ""
+		}
+		r := CardResult{
+			Nome:     campo("nome"),
+			Colecao:  campo("colecao"),
+			Numero:   campo("numero"),
+			Condicao: Condicao(campo("condicao")),
+			Lingua:   campo("lingua"),
+			Loja:     campo("loja"),
+			LojaID:   campo("loja_id"),
+			LojaURL:  campo("loja_url"),
+			Estado:   campo("estado"),
+		}
+		r.Quantidade, _ = strconv.Atoi(campo("quantidade"))
+		r.Preco, _ = strconv.ParseFloat(campo("preco"), 64)
+		r.PrecoTotal, _ = strconv.ParseFloat(campo("preco_total"), 64)
+		r.Frete, _ = strconv.ParseFloat(campo("frete"), 64)
+		r.QuantidadePedida, _ = strconv.Atoi(campo("quantidade_pedida"))
+		lista = append(lista, r)
+	}
+	return lista, nil
+}
+
+// Acrescenta ao histórico e regenera o resumo por carta
+func (s *csvStore) AnexarObservacoes(obs []Observacao) error {
+	if len(obs) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caminhoHist := s.caminho(config.HistoricoCSV)
+	return comTrava(caminhoHist, func() error {
+		if err := descartarLinhaCortada(caminhoHist); err != nil {
+			return err
+		}
+		err := anexarArquivo(caminhoHist, func(w io.Writer, novo bool) error {
+			return escreverHistoricoCSV(w, obs, novo)
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.resumo.atualizar(caminhoHist, lerHistoricoCSV); err != nil {
+			return err
+		}
+
+		// O resumo é reescrito inteiro: um crash no meio não pode truncá-lo.
+		// Com a trava do histórico, outro processo não grava um resumo mais velho por cima.
+		caminhoResumo := s.caminho(config.MonitorCSV)
+		return comTrava(caminhoResumo, func() error {
+			return gravarArquivoAtomico(caminhoResumo, func(w io.Writer) error {
+				return escreverMonitorCSV(w, s.resumo.entradas)
+			})
+		})
+	})
+}
+
+func (s *csvStore) UltimoPreco(nome, colecao, numero string) (preco float64, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caminho := s.caminho(config.HistoricoCSV)
+	err = comTrava(caminho, func() error {
+		if err := s.resumo.atualizar(caminho, lerHistoricoCSV); err != nil {
+			return err
+		}
+		preco, ok = s.resumo.ultimoPreco(nome, colecao, numero)
+		return nil
+	})
+	return preco, ok, err
+}
+
+func (s *csvStore) Observacoes(filtro FiltroObservacoes) ([]Observacao, error) {
+	s.mu.Lock()
+	var hist []Observacao
+	caminho := s.caminho(config.HistoricoCSV)
+	err := comTrava(caminho, func() (err error) {
+		hist, err = carregarHistoricoCSV(caminho)
+		return err
+	})
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var lista []Observacao
+	for _, o := range hist {
+		if filtro.aceita(o) {
+			lista = append(lista, o)
+		}
+	}
+	return lista, nil
+}
+
+// Jobs ficam todos num JSON só, reescrito a cada alteração
+func (s *csvStore) SalvarJob(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caminho := s.caminho(config.ArquivoJobs)
+	return comTrava(caminho, func() error {
+		var lista []*Job
+		if err := lerJSON(caminho, &lista); err != nil {
+			return err
+		}
+		substituido := false
+		for i, j := range lista {
+			if j.ID == job.ID {
+				lista[i], substituido = job, true
+				break
+			}
+		}
+		if !substituido {
+			lista = append(lista, job)
+		}
+		return gravarJSON(caminho, lista)
+	})
+}
+
+func (s *csvStore) Jobs() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lista []*Job
+	err := lerJSON(s.caminho(config.ArquivoJobs), &lista)
+	return lista, err
+}
+
+func (s *csvStore) SalvarMonitor(m *EstadoMonitor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caminho := s.caminho(arquivoMonitorAtivo)
+	return comTrava(caminho, func() error {
+		if m.ID == 0 {
+			var anterior EstadoMonitor
+			if err := lerJSON(caminho, &anterior); err != nil {
+				return err
+			}
+			m.ID = anterior.ID + 1
+		}
+		return gravarJSON(caminho, m)
+	})
+}
+
+func (s *csvStore) MonitorAtivo() (EstadoMonitor, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var m EstadoMonitor
+	if err := lerJSON(s.caminho(arquivoMonitorAtivo), &m); err != nil {
+		return m, false, err
+	}
+	return m, m.Ativo, nil
+}
+
+// Decodifica um arquivo JSON; arquivo inexistente deixa v como está.
+// Arquivos gravados com gravarJSON podem ser lidos sem a trava.
+func lerJSON(caminho string, v any) error {
+	dados, err := os.ReadFile(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(dados, v); err != nil {
+		return fmt.Errorf("%s: %v", filepath.Base(caminho), err)
+	}
+	return nil
+}
+
+// Reescreve o arquivo JSON de forma atômica (chamar com a trava do arquivo)
+func gravarJSON(caminho string, v any) error {
+	dados, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return gravarArquivoAtomico(caminho, func(w io.Writer) error {
+		_, err := w.Write(dados)
+		return err
+	})
+}
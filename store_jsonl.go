@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// --------------------------------------------------------------------------------
+// STORE JSON LINES
+// --------------------------------------------------------------------------------
+
+// Arquivos do store JSONL (na OutputFolder), um objeto JSON por linha
+const (
+	jsonlResultados  = "resultados.jsonl"
+	jsonlObservacoes = "observacoes.jsonl"
+	jsonlJobs        = "jobs.jsonl" // versões anteriores; convertido em jsonlPastaJobs ao abrir
+	jsonlMonitores   = "monitores.jsonl"
+)
+
+// Jobs mudam a cada carta concluída, então cada um fica num JSON próprio,
+// reescrito com gravarJSON, em vez de crescer o arquivo a cada alteração
+const jsonlPastaJobs = "jobs"
+
+// Os arquivos .jsonl só recebem linhas novas. Monitores são gravados
+// inteiros a cada alteração; na leitura vale a última linha de cada ID.
+type jsonlStore struct {
+	mu     sync.Mutex
+	pasta  string
+	resumo resumoIncremental // de observacoes.jsonl, para UltimoPreco
+}
+
+// Linha de resultados.jsonl: o resultado com a data da gravação
+type linhaResultado struct {
+	Data string `json:"data"`
+	CardResult
+}
+
+func abrirJSONLStore(pasta string) (*jsonlStore, error) {
+	if err := os.MkdirAll(filepath.Join(pasta, jsonlPastaJobs), 0755); err != nil {
+		return nil, err
+	}
+	s := &jsonlStore{pasta: pasta}
+	if err := s.converterJobsJSONL(); err != nil {
+		return nil, fmt.Errorf("%s: %v", jsonlJobs, err)
+	}
+	return s, nil
+}
+
+// O jobs.jsonl das versões anteriores vira um arquivo por job (a última
+// linha de cada ID) e é apagado
+func (s *jsonlStore) converterJobsJSONL() error {
+	return s.comArquivo(jsonlJobs, func(caminho string) error {
+		linhas, err := lerJSONL[*Job](caminho)
+		if err != nil || len(linhas) == 0 {
+			return err
+		}
+		ultimo := map[string]*Job{}
+		for _, j := range linhas {
+			ultimo[j.ID] = j
+		}
+		for _, j := range ultimo {
+			caminhoJob := s.caminhoJob(j.ID)
+			if err := comTrava(caminhoJob, func() error { return gravarJSON(caminhoJob, j) }); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("[STORE] %s convertido em %d arquivo(s) em %s/.\n", jsonlJobs, len(ultimo), jsonlPastaJobs)
+		return os.Remove(caminho)
+	})
+}
+
+func (s *jsonlStore) caminho(nome string) string {
+	return filepath.Join(s.pasta, nome)
+}
+
+func (s *jsonlStore) caminhoJob(id string) string {
+	return filepath.Join(s.pasta, jsonlPastaJobs, id+".json")
+}
+
+func (s *jsonlStore) Fechar() error {
+	return nil
+}
+
+// Acrescenta um objeto por linha ao arquivo (chamar com a trava do arquivo).
+// Se a última gravação foi cortada no meio, a linha incompleta é fechada
+// antes, para não corromper a primeira linha nova.
+func anexarJSONL[T any](caminho string, itens []T) error {
+	cortada, err := linhaFinalCortada(caminho)
+	if err != nil {
+		return err
+	}
+	return anexarArquivo(caminho, func(w io.Writer, novo bool) error {
+		if cortada {
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return err
+			}
+		}
+		enc := json.NewEncoder(w)
+		for _, item := range itens {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// O arquivo existe e não termina em '\n'?
+func linhaFinalCortada(caminho string) (bool, error) {
+	f, err := os.Open(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	ultimo := make([]byte, 1)
+	if _, err := f.ReadAt(ultimo, info.Size()-1); err != nil {
+		return false, err
+	}
+	return ultimo[0] != '\n', nil
+}
+
+// Lê todas as linhas do arquivo (vazio se ele não existe). Linhas que não
+// são JSON válido (gravação interrompida por um crash) são ignoradas.
+func lerJSONL[T any](caminho string) ([]T, error) {
+	f, err := os.Open(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return lerLinhasJSONL[T](f, filepath.Base(caminho))
+}
+
+// Lê as linhas de r; nome identifica o arquivo nos avisos
+func lerLinhasJSONL[T any](r io.Reader, nome string) ([]T, error) {
+	var lista []T
+	leitor := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		linha, err := leitor.ReadBytes('\n')
+		if len(bytes.TrimSpace(linha)) > 0 {
+			var item T
+			if errJSON := json.Unmarshal(linha, &item); errJSON != nil {
+				fmt.Printf("[STORE] %s, linha %d ignorada: %v\n", nome, n, errJSON)
+			} else {
+				lista = append(lista, item)
+			}
+		}
+		if err == io.EOF {
+			return lista, nil
+		}
+		if err != nil {
+			return lista, err
+		}
+	}
+}
+
+// Executa fn com o caminho do arquivo, sob o mutex e a trava do arquivo
+func (s *jsonlStore) comArquivo(nome string, fn func(caminho string) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caminho := s.caminho(nome)
+	return comTrava(caminho, func() error { return fn(caminho) })
+}
+
+func (s *jsonlStore) SalvarResultados(resultados []CardResult, dataStr string) error {
+	linhas := make([]linhaResultado, len(resultados))
+	for i, r := range resultados {
+		linhas[i] = linhaResultado{Data: dataStr, CardResult: r}
+	}
+	return s.comArquivo(jsonlResultados, func(caminho string) error {
+		return anexarJSONL(caminho, linhas)
+	})
+}
+
+func (s *jsonlStore) Resultados() ([]CardResult, error) {
+	var linhas []linhaResultado
+	err := s.comArquivo(jsonlResultados, func(caminho string) (err error) {
+		linhas, err = lerJSONL[linhaResultado](caminho)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var lista []CardResult
+	for _, l := range linhas {
+		lista = append(lista, l.CardResult)
+	}
+	return lista, nil
+}
+
+func (s *jsonlStore) LimparResultados() error {
+	return s.comArquivo(jsonlResultados, func(caminho string) error {
+		err := os.Remove(caminho)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	})
+}
+
+func (s *jsonlStore) AnexarObservacoes(obs []Observacao) error {
+	return s.comArquivo(jsonlObservacoes, func(caminho string) error {
+		return anexarJSONL(caminho, obs)
+	})
+}
+
+func (s *jsonlStore) Observacoes(filtro FiltroObservacoes) ([]Observacao, error) {
+	var todas []Observacao
+	err := s.comArquivo(jsonlObservacoes, func(caminho string) (err error) {
+		todas, err = lerJSONL[Observacao](caminho)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var lista []Observacao
+	for _, o := range todas {
+		if filtro.aceita(o) {
+			lista = append(lista, o)
+		}
+	}
+	return lista, nil
+}
+
+func (s *jsonlStore) UltimoPreco(nome, colecao, numero string) (preco float64, ok bool, err error) {
+	err = s.comArquivo(jsonlObservacoes, func(caminho string) error {
+		err := s.resumo.atualizar(caminho, func(trecho io.Reader, _ bool) ([]Observacao, error) {
+			return lerLinhasJSONL[Observacao](trecho, jsonlObservacoes)
+		})
+		if err != nil {
+			return err
+		}
+		preco, ok = s.resumo.ultimoPreco(nome, colecao, numero)
+		return nil
+	})
+	return preco, ok, err
+}
+
+func (s *jsonlStore) SalvarJob(job *Job) error {
+	return s.comArquivo(filepath.Join(jsonlPastaJobs, job.ID+".json"), func(caminho string) error {
+		return gravarJSON(caminho, job)
+	})
+}
+
+// Todos os jobs, na ordem em que foram criados. Os arquivos são gravados
+// com gravarJSON, então podem ser lidos sem a trava.
+func (s *jsonlStore) Jobs() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	arquivos, err := filepath.Glob(filepath.Join(s.pasta, jsonlPastaJobs, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var lista []*Job
+	for _, a := range arquivos {
+		var j *Job
+		if err := lerJSON(a, &j); err != nil {
+			return nil, err
+		}
+		if j != nil {
+			lista = append(lista, j)
+		}
+	}
+	slices.SortStableFunc(lista, func(a, b *Job) int { return a.CriadoEm.Compare(b.CriadoEm) })
+	return lista, nil
+}
+
+func (s *jsonlStore) SalvarMonitor(m *EstadoMonitor) error {
+	return s.comArquivo(jsonlMonitores, func(caminho string) error {
+		if m.ID == 0 {
+			linhas, err := lerJSONL[EstadoMonitor](caminho)
+			if err != nil {
+				return err
+			}
+			m.ID = 1
+			for _, l := range linhas {
+				m.ID = max(m.ID, l.ID+1)
+			}
+		}
+		return anexarJSONL(caminho, []EstadoMonitor{*m})
+	})
+}
+
+// Monitor ativo mais recente, considerando a última linha de cada ID
+func (s *jsonlStore) MonitorAtivo() (EstadoMonitor, bool, error) {
+	var linhas []EstadoMonitor
+	err := s.comArquivo(jsonlMonitores, func(caminho string) (err error) {
+		linhas, err = lerJSONL[EstadoMonitor](caminho)
+		return err
+	})
+	if err != nil {
+		return EstadoMonitor{}, false, err
+	}
+	ultimo := map[int64]EstadoMonitor{}
+	for _, l := range linhas {
+		ultimo[l.ID] = l
+	}
+	var (
+		ativo EstadoMonitor
+		ok    bool
+	)
+	for _, m := range ultimo {
+		if m.Ativo && m.ID > ativo.ID {
+			ativo, ok = m, true
+		}
+	}
+	return ativo, ok, nil
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // driver "sqlite", sem CGO
+)
+
+// --------------------------------------------------------------------------------
+// STORE SQLITE
+// --------------------------------------------------------------------------------
+
+// Migrações do schema, aplicadas em ordem. A versão atual fica em
+// PRAGMA user_version; migrações já aplicadas nunca devem ser alteradas,
+// só acrescentadas.
+var migracoesSQLite = []string{
+	// 1: schema inicial
+	`CREATE TABLE cartas (
+		id      INTEGER PRIMARY KEY,
+		chave   TEXT NOT NULL UNIQUE, -- chaveCarta(nome, colecao, numero)
+		nome    TEXT NOT NULL,
+		colecao TEXT NOT NULL,
+		numero  TEXT NOT NULL
+	);
+	CREATE TABLE resultados (
+		id                INTEGER PRIMARY KEY,
+		carta_id          INTEGER NOT NULL REFERENCES cartas(id),
+		data              TEXT NOT NULL,
+		condicao          TEXT NOT NULL,
+		quantidade        INTEGER NOT NULL,
+		preco             REAL NOT NULL,
+		preco_total       REAL NOT NULL,
+		frete             REAL NOT NULL,
+		quantidade_pedida INTEGER NOT NULL,
+		lingua            TEXT NOT NULL,
+		loja              TEXT NOT NULL,
+		loja_id           TEXT NOT NULL,
+		loja_url          TEXT NOT NULL,
+		estado            TEXT NOT NULL
+	);
+	CREATE TABLE observacoes (
+		id          INTEGER PRIMARY KEY,
+		carta_id    INTEGER NOT NULL REFERENCES cartas(id),
+		data        TEXT NOT NULL,
+		checagem    TEXT NOT NULL,
+		loja        TEXT NOT NULL,
+		loja_id     TEXT NOT NULL,
+		estado      TEXT NOT NULL,
+		condicao    TEXT NOT NULL,
+		lingua      TEXT NOT NULL,
+		preco       REAL NOT NULL,
+		preco_total REAL NOT NULL,
+		quantidade  INTEGER NOT NULL,
+		selecionada INTEGER NOT NULL
+	);
+	CREATE INDEX observacoes_carta_data ON observacoes(carta_id, data);
+	CREATE TABLE monitores (
+		id          INTEGER PRIMARY KEY,
+		request     TEXT NOT NULL, -- ScrapeRequest em JSON
+		ativo       INTEGER NOT NULL,
+		pausado     INTEGER NOT NULL,
+		iniciado_em TEXT NOT NULL
+	);
+	CREATE TABLE jobs (
+		id            TEXT PRIMARY KEY,
+		status        TEXT NOT NULL,
+		criado_em     TEXT NOT NULL,
+		atualizado_em TEXT NOT NULL,
+		dados         TEXT NOT NULL -- Job completo em JSON
+	);`,
+}
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// Abre (ou cria) o banco e aplica as migrações pendentes
+func abrirSQLiteStore(caminho string) (*sqliteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(caminho), 0755); err != nil {
+		return nil, err
+	}
+	dsn := "file:" + caminho + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// Uma conexão só: o SQLite serializa as escritas de qualquer forma
+	db.SetMaxOpenConns(1)
+	s := &sqliteStore{db: db}
+	if err := s.migrar(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migração de %s: %v", caminho, err)
+	}
+	return s, nil
+}
+
+func (s *sqliteStore) migrar() error {
+	var versao int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&versao); err != nil {
+		return err
+	}
+	if versao > len(migracoesSQLite) {
+		return fmt.Errorf("banco na versão %d, mais nova que este programa (%d)", versao, len(migracoesSQLite))
+	}
+	for v := versao; v < len(migracoesSQLite); v++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migracoesSQLite[v]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("versão %d: %v", v+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		fmt.Printf("[STORE] Schema migrado para a versão %d.\n", v+1)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Fechar() error {
+	return s.db.Close()
+}
+
+// ID da carta, cadastrando-a se preciso
+func idCarta(tx *sql.Tx, nome, colecao, numero string) (int64, error) {
+	chave := chaveCarta(nome, colecao, numero)
+	if _, err := tx.Exec(`INSERT INTO cartas (chave, nome, colecao, numero) VALUES (?, ?, ?, ?)
+		ON CONFLICT (chave) DO NOTHING`, chave, nome, colecao, numero); err != nil {
+		return 0, err
+	}
+	var id int64
+	err := tx.QueryRow(`SELECT id FROM cartas WHERE chave = ?`, chave).Scan(&id)
+	return id, err
+}
+
+// Executa fn numa transação, com commit se não houver erro
+func (s *sqliteStore) transacao(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) SalvarResultados(resultados []CardResult, dataStr string) error {
+	return s.transacao(func(tx *sql.Tx) error {
+		for _, r := range resultados {
+			id, err := idCarta(tx, r.Nome, r.Colecao, r.Numero)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`INSERT INTO resultados (carta_id, data, condicao, quantidade, preco, preco_total,
+				frete, quantidade_pedida, lingua, loja, loja_id, loja_url, estado)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				id, dataStr, string(r.Condicao), r.Quantidade, r.Preco, r.PrecoTotal,
+				r.Frete, r.QuantidadePedida, r.Lingua, r.Loja, r.LojaID, r.LojaURL, r.Estado); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqliteStore) Resultados() ([]CardResult, error) {
+	rows, err := s.db.Query(`SELECT c.nome, c.colecao, c.numero, r.condicao, r.quantidade, r.preco, r.preco_total,
+		r.frete, r.quantidade_pedida, r.lingua, r.loja, r.loja_id, r.loja_url, r.estado
+		FROM resultados r JOIN cartas c ON c.id = r.carta_id ORDER BY r.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lista []CardResult
+	for rows.Next() {
+		var r CardResult
+		if err := rows.Scan(&r.Nome, &r.Colecao, &r.Numero, &r.Condicao, &r.Quantidade, &r.Preco, &r.PrecoTotal,
+			&r.Frete, &r.QuantidadePedida, &r.Lingua, &r.Loja, &r.LojaID, &r.LojaURL, &r.Estado); err != nil {
+			return nil, err
+		}
+		lista = append(lista, r)
+	}
+	return lista, rows.Err()
+}
+
+func (s *sqliteStore) LimparResultados() error {
+	_, err := s.db.Exec(`DELETE FROM resultados`)
+	return err
+}
+
+func (s *sqliteStore) AnexarObservacoes(obs []Observacao) error {
+	return s.transacao(func(tx *sql.Tx) error {
+		for _, o := range obs {
+			id, err := idCarta(tx, o.Nome, o.Colecao, o.Numero)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`INSERT INTO observacoes (carta_id, data, checagem, loja, loja_id, estado,
+				condicao, lingua, preco, preco_total, quantidade, selecionada)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				id, o.Data, o.Checagem, o.Loja, o.LojaID, o.Estado,
+				string(o.Condicao), o.Lingua, o.Preco, o.PrecoTotal, o.Quantidade, o.Selecionada); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqliteStore) Observacoes(filtro FiltroObservacoes) ([]Observacao, error) {
+	var (
+		cond []string
+		args []any
+	)
+	if filtro.Nome != "" {
+		cond = append(cond, "c.chave = ?")
+		args = append(args, chaveCarta(filtro.Nome, filtro.Colecao, filtro.Numero))
+	}
+	if filtro.De != "" {
+		cond = append(cond, "o.data >= ?")
+		args = append(args, filtro.De)
+	}
+	if filtro.Ate != "" {
+		cond = append(cond, "o.data <= ?")
+		args = append(args, filtro.Ate)
+	}
+	consulta := `SELECT o.data, o.checagem, c.nome, c.colecao, c.numero, o.loja, o.loja_id, o.estado,
+		o.condicao, o.lingua, o.preco, o.preco_total, o.quantidade, o.selecionada
+		FROM observacoes o JOIN cartas c ON c.id = o.carta_id`
+	if len(cond) > 0 {
+		consulta += " WHERE " + strings.Join(cond, " AND ")
+	}
+	consulta += " ORDER BY o.id"
+
+	rows, err := s.db.Query(consulta, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lista []Observacao
+	for rows.Next() {
+		var o Observacao
+		if err := rows.Scan(&o.Data, &o.Checagem, &o.Nome, &o.Colecao, &o.Numero, &o.Loja, &o.LojaID, &o.Estado,
+			&o.Condicao, &o.Lingua, &o.Preco, &o.PrecoTotal, &o.Quantidade, &o.Selecionada); err != nil {
+			return nil, err
+		}
+		lista = append(lista, o)
+	}
+	return lista, rows.Err()
+}
+
+func (s *sqliteStore) UltimoPreco(nome, colecao, numero string) (float64, bool, error) {
+	var preco float64
+	err := s.db.QueryRow(`SELECT o.preco FROM observacoes o JOIN cartas c ON c.id = o.carta_id
+		WHERE c.chave = ? AND o.selecionada = 1 ORDER BY o.id DESC LIMIT 1`,
+		chaveCarta(nome, colecao, numero)).Scan(&preco)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return preco, err == nil, err
+}
+
+func (s *sqliteStore) SalvarJob(job *Job) error {
+	dados, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO jobs (id, status, criado_em, atualizado_em, dados) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET status = excluded.status, atualizado_em = excluded.atualizado_em, dados = excluded.dados`,
+		job.ID, job.Status, job.CriadoEm.Format(time.RFC3339Nano), job.AtualizadoEm.Format(time.RFC3339Nano), string(dados))
+	return err
+}
+
+func (s *sqliteStore) Jobs() ([]*Job, error) {
+	rows, err := s.db.Query(`SELECT dados FROM jobs ORDER BY criado_em`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lista []*Job
+	for rows.Next() {
+		var dados string
+		if err := rows.Scan(&dados); err != nil {
+			return nil, err
+		}
+		var job Job
+		if err := json.Unmarshal([]byte(dados), &job); err != nil {
+			return nil, err
+		}
+		lista = append(lista, &job)
+	}
+	return lista, rows.Err()
+}
+
+func (s *sqliteStore) SalvarMonitor(m *EstadoMonitor) error {
+	req, err := json.Marshal(m.Request)
+	if err != nil {
+		return err
+	}
+	if m.ID == 0 {
+		res, err := s.db.Exec(`INSERT INTO monitores (request, ativo, pausado, iniciado_em) VALUES (?, ?, ?, ?)`,
+			string(req), m.Ativo, m.Pausado, m.IniciadoEm)
+		if err != nil {
+			return err
+		}
+		m.ID, err = res.LastInsertId()
+		return err
+	}
+	_, err = s.db.Exec(`UPDATE monitores SET request = ?, ativo = ?, pausado = ?, iniciado_em = ? WHERE id = ?`,
+		string(req), m.Ativo, m.Pausado, m.IniciadoEm, m.ID)
+	return err
+}
+
+func (s *sqliteStore) MonitorAtivo() (EstadoMonitor, bool, error) {
+	var (
+		m   EstadoMonitor
+		req string
+	)
+	err := s.db.QueryRow(`SELECT id, request, ativo, pausado, iniciado_em FROM monitores
+		WHERE ativo = 1 ORDER BY id DESC LIMIT 1`).Scan(&m.ID, &req, &m.Ativo, &m.Pausado, &m.IniciadoEm)
+	if err == sql.ErrNoRows {
+		return m, false, nil
+	}
+	if err != nil {
+		return m, false, err
+	}
+	if err := json.Unmarshal([]byte(req), &m.Request); err != nil {
+		return m, false, err
+	}
+	return m, true, nil
+}
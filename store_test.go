@@ -0,0 +1,293 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Implementações testadas pela suíte de conformidade; todas abrem na pasta dada
+var implementacoesStore = map[string]func(pasta string) (Store, error){
+	ArmazenamentoSQLite: func(pasta string) (Store, error) { return abrirSQLiteStore(filepath.Join(pasta, "teste.db")) },
+	ArmazenamentoCSV:    func(pasta string) (Store, error) { return abrirCSVStore(pasta) },
+	ArmazenamentoJSONL:  func(pasta string) (Store, error) { return abrirJSONLStore(pasta) },
+}
+
+func TestStoreConformidade(t *testing.T) {
+	for nome, abrir := range implementacoesStore {
+		t.Run(nome, func(t *testing.T) {
+			configOriginal := config
+			t.Cleanup(func() { config = configOriginal })
+			config.OutputFolder = t.TempDir()
+
+			reabrir := func(anterior Store) Store {
+				t.Helper()
+				if anterior != nil {
+					anterior.Fechar()
+				}
+				st, err := abrir(config.OutputFolder)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return st
+			}
+			st := reabrir(nil)
+			t.Cleanup(func() { st.Fechar() })
+
+			t.Run("resultados", func(t *testing.T) { conformidadeResultados(t, st) })
+			t.Run("observacoes", func(t *testing.T) { conformidadeObservacoes(t, st) })
+			t.Run("jobs", func(t *testing.T) { conformidadeJobs(t, st) })
+			t.Run("monitor", func(t *testing.T) { conformidadeMonitor(t, st) })
+			t.Run("concorrencia", func(t *testing.T) { conformidadeConcorrencia(t, st) })
+
+			// Tudo continua lá depois de reabrir
+			st = reabrir(st)
+			if rs, err := st.Resultados(); err != nil || len(rs) != 1 {
+				t.Errorf("resultados após reabrir = %+v, err %v", rs, err)
+			}
+			if obs, err := st.Observacoes(FiltroObservacoes{}); err != nil || len(obs) != 5+20 {
+				t.Errorf("observações após reabrir: %d, err %v", len(obs), err)
+			}
+			if jobs, err := st.Jobs(); err != nil || len(jobs) != 2 || jobs[0].Status != JobConcluido {
+				t.Errorf("jobs após reabrir = %+v, err %v", jobs, err)
+			}
+			if preco, ok, err := st.UltimoPreco("Charizard ex", "OBF", "125"); err != nil || !ok || preco != 280 {
+				t.Errorf("último preço após reabrir = %v (ok=%v), err %v", preco, ok, err)
+			}
+			if m, ok, err := st.MonitorAtivo(); err != nil || !ok || !m.Pausado {
+				t.Errorf("monitor após reabrir = %+v (ok=%v), err %v", m, ok, err)
+			}
+		})
+	}
+}
+
+func conformidadeResultados(t *testing.T, st Store) {
+	if rs, err := st.Resultados(); err != nil || len(rs) != 0 {
+		t.Fatalf("store novo com resultados: %+v, err %v", rs, err)
+	}
+	a := CardResult{
+		Nome: "Charizard ex", Colecao: "OBF", Numero: "125", Condicao: CondicaoNM, Quantidade: 2,
+		Preco: 350, PrecoTotal: 370, Frete: 20, QuantidadePedida: 1, Lingua: LinguaPT,
+		Loja: "Loja Alfa", LojaID: "101", LojaURL: "https://loja/101", Estado: "SP",
+	}
+	b := a
+	b.Loja, b.Preco = "Loja Beta", 320.5
+	if err := st.SalvarResultados([]CardResult{a, b}, "2024-01-01 10:00:00"); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := st.Resultados()
+	if err != nil || len(rs) != 2 || rs[0] != a || rs[1] != b {
+		t.Fatalf("resultados = %+v, err %v", rs, err)
+	}
+
+	if err := st.LimparResultados(); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.LimparResultados(); err != nil {
+		t.Errorf("limpar de novo: %v", err)
+	}
+	if err := st.SalvarResultados([]CardResult{a}, "2024-01-02 10:00:00"); err != nil {
+		t.Fatal(err)
+	}
+	if rs, err := st.Resultados(); err != nil || len(rs) != 1 || rs[0] != a {
+		t.Errorf("resultados após limpar = %+v, err %v", rs, err)
+	}
+}
+
+func conformidadeObservacoes(t *testing.T, st Store) {
+	base := Observacao{Nome: "Charizard ex", Colecao: "OBF", Numero: "125", Loja: "Loja Alfa", Condicao: CondicaoNM, Quantidade: 1, Selecionada: true}
+	obs := []Observacao{base, base, base, base}
+	obs[0].Data, obs[0].Checagem, obs[0].Preco = "2024-01-01 10:00:00", "c1", 300
+	obs[1].Data, obs[1].Checagem, obs[1].Preco, obs[1].Selecionada = "2024-01-01 10:00:00", "c1", 310, false
+	obs[2].Data, obs[2].Checagem, obs[2].Preco = "2024-01-02 10:00:00", "c2", 280
+	obs[3] = Observacao{Data: "2024-01-02 10:00:00", Checagem: "c2", Nome: "Pikachu", Colecao: "SVP", Numero: "27", Preco: 10.25, Selecionada: true}
+	if err := st.AnexarObservacoes(obs[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AnexarObservacoes(obs[2:]); err != nil {
+		t.Fatal(err)
+	}
+
+	todas, err := st.Observacoes(FiltroObservacoes{})
+	if err != nil || len(todas) != 4 {
+		t.Fatalf("observações = %+v, err %v", todas, err)
+	}
+	for i := range obs {
+		if todas[i] != obs[i] {
+			t.Errorf("observação %d = %+v\nesperado %+v", i, todas[i], obs[i])
+		}
+	}
+
+	filtros := map[FiltroObservacoes]int{
+		{Nome: "CHARIZARD EX", Colecao: "obf", Numero: "125"}: 3,
+		{Nome: "Pikachu", Colecao: "SVP", Numero: "27"}:       1,
+		{Nome: "Mewtwo"}:             0,
+		{De: "2024-01-02 00:00:00"}:  2,
+		{Ate: "2024-01-01 10:00:00"}: 2,
+		{Nome: "Charizard ex", Colecao: "OBF", Numero: "125", De: "2024-01-02 10:00:00"}: 1,
+	}
+	for f, n := range filtros {
+		if lista, err := st.Observacoes(f); err != nil || len(lista) != n {
+			t.Errorf("filtro %+v: %d observações (esperado %d), err %v", f, len(lista), n, err)
+		}
+	}
+
+	// Só as selecionadas contam: a de 310 não é a última
+	if preco, ok, err := st.UltimoPreco("charizard ex", "OBF", "125"); err != nil || !ok || preco != 280 {
+		t.Errorf("último preço = %v (ok=%v), err %v", preco, ok, err)
+	}
+	if _, ok, err := st.UltimoPreco("Mewtwo", "MEW", "150"); err != nil || ok {
+		t.Errorf("carta sem observações com preço (ok=%v), err %v", ok, err)
+	}
+	if err := st.AnexarObservacoes([]Observacao{{Data: "2024-01-02 11:00:00", Checagem: "c2b", Nome: "Pikachu", Colecao: "SVP", Numero: "27", Preco: 11, Selecionada: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if preco, ok, err := st.UltimoPreco("Pikachu", "SVP", "27"); err != nil || !ok || preco != 11 {
+		t.Errorf("último preço após anexar = %v (ok=%v), err %v", preco, ok, err)
+	}
+}
+
+func conformidadeJobs(t *testing.T, st Store) {
+	agora := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	j1 := &Job{ID: "j1", Status: JobPendente, Request: ScrapeRequest{Cards: []CardInput{charizard}}, Cartas: []CartaJob{{Card: charizard}}, CriadoEm: agora, AtualizadoEm: agora}
+	j2 := &Job{ID: "j2", Status: JobPendente, CriadoEm: agora.Add(time.Minute), AtualizadoEm: agora.Add(time.Minute)}
+	for _, j := range []*Job{j1, j2} {
+		if err := st.SalvarJob(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j1.Status = JobConcluido
+	j1.AtualizadoEm = agora.Add(2 * time.Minute)
+	j1.Cartas[0].Concluida = true
+	if err := st.SalvarJob(j1); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := st.Jobs()
+	if err != nil || len(jobs) != 2 {
+		t.Fatalf("jobs = %+v, err %v", jobs, err)
+	}
+	if j := jobs[0]; j.ID != "j1" || j.Status != JobConcluido || !j.AtualizadoEm.Equal(j1.AtualizadoEm) || len(j.Cartas) != 1 || !j.Cartas[0].Concluida {
+		t.Errorf("job atualizado = %+v", j)
+	}
+	if jobs[1].ID != "j2" || jobs[1].Status != JobPendente {
+		t.Errorf("segundo job = %+v", jobs[1])
+	}
+}
+
+func conformidadeMonitor(t *testing.T, st Store) {
+	if _, ok, err := st.MonitorAtivo(); err != nil || ok {
+		t.Fatalf("store novo com monitor ativo (ok=%v), err %v", ok, err)
+	}
+	antigo := EstadoMonitor{Request: ScrapeRequest{Cards: []CardInput{charizard}}, Ativo: true, IniciadoEm: "2024-01-01 10:00:00"}
+	if err := st.SalvarMonitor(&antigo); err != nil || antigo.ID == 0 {
+		t.Fatalf("ID = %d, err %v", antigo.ID, err)
+	}
+	antigo.Ativo = false
+	if err := st.SalvarMonitor(&antigo); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := st.MonitorAtivo(); ok {
+		t.Error("monitor parado continua ativo")
+	}
+
+	novo := EstadoMonitor{Request: ScrapeRequest{Cards: []CardInput{charizard}, Modo: ModoTodas}, Ativo: true, IniciadoEm: "2024-01-02 10:00:00"}
+	if err := st.SalvarMonitor(&novo); err != nil || novo.ID == antigo.ID {
+		t.Fatalf("ID = %d (anterior %d), err %v", novo.ID, antigo.ID, err)
+	}
+	novo.Pausado = true
+	if err := st.SalvarMonitor(&novo); err != nil {
+		t.Fatal(err)
+	}
+	m, ok, err := st.MonitorAtivo()
+	if err != nil || !ok || m.ID != novo.ID || !m.Pausado || m.Request.Modo != ModoTodas || len(m.Request.Cards) != 1 {
+		t.Errorf("monitor ativo = %+v (ok=%v), err %v", m, ok, err)
+	}
+}
+
+// /scrape, monitor e jobs gravam ao mesmo tempo
+func conformidadeConcorrencia(t *testing.T, st Store) {
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o := Observacao{Data: "2024-01-03 10:00:00", Checagem: "c3", Nome: "Pikachu", Colecao: "SVP", Numero: "27", Preco: float64(i), Selecionada: true}
+			if err := st.AnexarObservacoes([]Observacao{o}); err != nil {
+				t.Error(err)
+			}
+			if _, err := st.Observacoes(FiltroObservacoes{Nome: "Pikachu", Colecao: "SVP", Numero: "27"}); err != nil {
+				t.Error(err)
+			}
+			if _, _, err := st.UltimoPreco("Pikachu", "SVP", "27"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if lista, _ := st.Observacoes(FiltroObservacoes{De: "2024-01-03 00:00:00"}); len(lista) != 20 {
+		t.Errorf("%d observações gravadas em paralelo, esperado 20", len(lista))
+	}
+}
+
+// resultados_final.csv gravado pela versão antiga, só com as 8 colunas de então
+func TestCSVStoreResultadosLegado(t *testing.T) {
+	configOriginal := config
+	t.Cleanup(func() { config = configOriginal })
+	config.OutputFolder = t.TempDir()
+	caminho := filepath.Join(config.OutputFolder, config.SaidaCSV)
+	legado := "nome;colecao;numero;condicao;quantidade;preco;preco_total;lingua\n" +
+		"Pikachu;SVP;27;NM;3;10.50;10.50;PT\n"
+	if err := os.WriteFile(caminho, []byte(legado), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := abrirCSVStore(config.OutputFolder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs, err := st.Resultados(); err != nil || len(rs) != 1 || rs[0].Nome != "Pikachu" || rs[0].Preco != 10.5 {
+		t.Fatalf("resultados do arquivo antigo = %+v, err %v", rs, err)
+	}
+	novo := CardResult{Nome: "Charizard ex", Colecao: "OBF", Numero: "125", Condicao: CondicaoNM, Quantidade: 2, Preco: 350, PrecoTotal: 370, Frete: 20, QuantidadePedida: 1, Lingua: LinguaPT, Loja: "Loja Alfa", LojaID: "101", LojaURL: "https://loja/101", Estado: "SP"}
+	if err := st.SalvarResultados([]CardResult{novo}, "2024-01-01 10:00:00"); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := st.Resultados()
+	if err != nil || len(rs) != 2 || rs[0].Nome != "Pikachu" || rs[0].Lingua != "PT" || rs[1] != novo {
+		t.Errorf("resultados após anexar = %+v, err %v", rs, err)
+	}
+	dados, _ := os.ReadFile(caminho)
+	if cabecalho, _, _ := strings.Cut(string(dados), "\n"); cabecalho != strings.Join(colunasResultadosCSV, ";") {
+		t.Errorf("cabeçalho = %q", cabecalho)
+	}
+}
+
+// jobs.jsonl de versões anteriores, com uma linha por alteração do job
+func TestJSONLStoreConverteJobs(t *testing.T) {
+	pasta := t.TempDir()
+	agora := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	linhas := []*Job{
+		{ID: "j1", Status: JobPendente, CriadoEm: agora},
+		{ID: "j2", Status: JobPendente, CriadoEm: agora.Add(time.Minute)},
+		{ID: "j1", Status: JobConcluido, CriadoEm: agora},
+	}
+	if err := anexarJSONL(filepath.Join(pasta, jsonlJobs), linhas); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := abrirJSONLStore(pasta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := st.Jobs()
+	if err != nil || len(jobs) != 2 || jobs[0].ID != "j1" || jobs[0].Status != JobConcluido || jobs[1].ID != "j2" {
+		t.Errorf("jobs convertidos = %+v, err %v", jobs, err)
+	}
+	if _, err := os.Stat(filepath.Join(pasta, jsonlJobs)); !os.IsNotExist(err) {
+		t.Errorf("%s continua lá: %v", jsonlJobs, err)
+	}
+}
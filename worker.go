@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// --------------------------------------------------------------------------------
+// PROCESSAMENTO PARALELO DAS CARTAS
+// --------------------------------------------------------------------------------
+
+// Resultado da busca de uma carta, na mesma posição da lista de entrada
+type resultadoCarta struct {
+	Indice     int // posição da carta na lista de entrada
+	Card       CardInput
+	Resultados []CardResult
+	Err        error
+	Tentativas int
+	Duracao    time.Duration // tempo total da carta, incluindo retries
+}
+
+// Busca as cartas com até config.Concorrencia workers em paralelo. O retorno
+// preserva a ordem da entrada. aoIniciar (opcional) é chamado quando um worker
+// pega a carta; aoConcluir (opcional) a cada carta terminada, uma chamada por
+// vez, com o total de cartas já concluídas.
+// Cada tentativa tem o prazo config.TimeoutPorCarta e os erros são
+// tentados de novo conforme config.Retry. Se ctx for cancelado, as
+// cartas ainda não iniciadas ficam com Err = ctx.Err() sem chamar aoConcluir.
+func processarCartas(ctx context.Context, scraper Scraper, cards []CardInput, opts OpcoesBusca, aoIniciar func(i int, card CardInput), aoConcluir func(feitas int, r resultadoCarta)) []resultadoCarta {
+	resultados := make([]resultadoCarta, len(cards))
+	workers := config.Concorrencia
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(cards) {
+		workers = len(cards)
+	}
+
+	indices := make(chan int)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		feitas int
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if aoIniciar != nil {
+					aoIniciar(i, cards[i])
+				}
+				inicio := time.Now()
+				ret, tentativas, err := buscaComRetry(ctx, scraper, cards[i], opts)
+				r := resultadoCarta{Indice: i, Card: cards[i], Resultados: ret, Err: err, Tentativas: tentativas, Duracao: time.Since(inicio)}
+				resultados[i] = r
+
+				mu.Lock()
+				feitas++
+				if aoConcluir != nil {
+					aoConcluir(feitas, r)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	enviadas := 0
+despacho:
+	for enviadas < len(cards) {
+		select {
+		case indices <- enviadas:
+			enviadas++
+		case <-ctx.Done():
+			break despacho
+		}
+	}
+	close(indices)
+	wg.Wait()
+	for i := enviadas; i < len(cards); i++ {
+		resultados[i] = resultadoCarta{Indice: i, Card: cards[i], Err: ctx.Err()}
+	}
+	return resultados
+}
+
+// Uma tentativa de busca, respeitando config.TimeoutPorCarta
+func buscaComPrazo(ctx context.Context, scraper Scraper, card CardInput, opts OpcoesBusca) ([]CardResult, error) {
+	if config.TimeoutPorCarta > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.TimeoutPorCarta)
+		defer cancel()
+	}
+	return scraper.Busca(ctx, card, opts)
+}
+
+// --------------------------------------------------------------------------------
+// POLIDEZ POR HOST
+// --------------------------------------------------------------------------------
+
+// Garante um intervalo mínimo entre requisições ao mesmo host, mesmo com
+// vários workers, para não sobrecarregar o site
+type limitadorHosts struct {
+	mu      sync.Mutex
+	proxima map[string]time.Time // próximo horário livre de cada host
+}
+
+var limitadorPolidez = &limitadorHosts{proxima: map[string]time.Time{}}
+
+// Bloqueia até ser a vez de acessar o host da URL (config.IntervaloPorHost).
+// Retorna ctx.Err() se o contexto for cancelado durante a espera.
+func (l *limitadorHosts) esperarVez(ctx context.Context, rawURL string) error {
+	intervalo := config.IntervaloPorHost
+	if intervalo <= 0 {
+		return ctx.Err()
+	}
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	l.mu.Lock()
+	agora := time.Now()
+	vez := l.proxima[host]
+	if vez.Before(agora) {
+		vez = agora
+	}
+	l.proxima[host] = vez.Add(intervalo)
+	l.mu.Unlock()
+
+	return esperar(ctx, time.Until(vez))
+}